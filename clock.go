@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var lastGameID int64
+
+func nextGameID() int {
+	return int(atomic.AddInt64(&lastGameID, 1))
+}
+
+// tickMsg redraws the clock once a second. It carries the ID of the game
+// that started it so that a game replaced by a newer one stops ticking.
+type tickMsg struct {
+	id int
+}
+
+func (m GameModel) tick() tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return tickMsg{id: m.id}
+	})
+}
+
+// elapsed is the total solving time, including time played before the game
+// was last resumed but not time spent paused or in menus.
+func (m GameModel) elapsed() time.Duration {
+	if !m.clockRunning {
+		return m.elapsedBefore
+	}
+	return m.elapsedBefore + time.Since(m.startTime)
+}
+
+func (m *GameModel) pauseClock() {
+	if m.clockRunning {
+		m.elapsedBefore += time.Since(m.startTime)
+		m.clockRunning = false
+	}
+}
+
+func (m *GameModel) resumeClock() {
+	if !m.clockRunning {
+		m.startTime = time.Now()
+		m.clockRunning = true
+	}
+}
+
+// leaveBoard stops the clock while the player is away from the board in
+// state, such as the pause screen or the game menu.
+func (m *GameModel) leaveBoard(state GameState) {
+	m.resumeState = m.state
+	m.state = state
+	m.pauseClock()
+}
+
+// resume takes the player back to the board as they left it, so a board
+// that still needs correcting does.
+func (m *GameModel) resume() {
+	m.state = m.resumeState
+	m.resumeClock()
+	// A co-op partner may have changed the shared board meanwhile.
+	if m.inRoom(coopMode) {
+		m.refreshState()
+	}
+}
+
+func (m GameModel) renderPaused() string {
+	elapsedTime := m.elapsed().Round(time.Second)
+	message := fmt.Sprintf("%s\n\nElapsed time: %02d:%02d\n\n%s",
+		lipgloss.NewStyle().Bold(true).Render("Paused"),
+		int(elapsedTime.Minutes()), int(elapsedTime.Seconds())%60,
+		m.help.View(m.helpKeys()))
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, message)
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/joho/godotenv"
+)
+
+const defaultConfigFileName = "sudoku.toml"
+
+// Config is the server configuration. Settings are read from, in increasing
+// order of precedence: built-in defaults, a TOML config file, a .env file,
+// environment variables and command line flags.
+type Config struct {
+	Host             string        `toml:"host"`
+	Port             int           `toml:"port"`
+	HostKeyPath      string        `toml:"host_key_path"`
+	DataDir          string        `toml:"data_dir"`
+	LeaderboardStore string        `toml:"leaderboard_store"`
+	IdleTimeout      time.Duration `toml:"idle_timeout"`
+	MaxSessions      int           `toml:"max_sessions"`
+	// ThemesFile defines extra color themes. It defaults to
+	// sudoku_themes.toml in the data dir, which needn't exist.
+	ThemesFile string `toml:"themes_file"`
+	// KeyMapsFile defines extra key maps. It defaults to
+	// sudoku_keymaps.toml in the data dir, which needn't exist.
+	KeyMapsFile string `toml:"keymaps_file"`
+	// DailySecret is mixed into the daily puzzles' seeds so that they can't
+	// be worked out ahead of time. If it isn't set, a random secret is kept
+	// in sudoku_daily_secret in the data dir.
+	DailySecret string      `toml:"daily_secret"`
+	Admin       AdminConfig `toml:"admin"`
+}
+
+type AdminConfig struct {
+	// Password takes precedence over PasswordFile. If neither is set the
+	// admin_password.txt file is looked up in the usual places.
+	Password     string `toml:"password"`
+	PasswordFile string `toml:"password_file"`
+}
+
+var config = DefaultConfig()
+
+func DefaultConfig() *Config {
+	return &Config{
+		Host:             "localhost",
+		Port:             23234,
+		HostKeyPath:      ".ssh/term_info_ed25519",
+		DataDir:          ".",
+		LeaderboardStore: "json",
+	}
+}
+
+// setting is one configuration value that can be given as a flag or an
+// environment variable.
+type setting struct {
+	flag, env, usage string
+	apply            func(c *Config, value string) error
+}
+
+var settings = []setting{
+	{"host", "SUDOKU_HOST", "host to listen on", func(c *Config, v string) error {
+		c.Host = v
+		return nil
+	}},
+	{"port", "SUDOKU_PORT", "port to listen on", func(c *Config, v string) error {
+		port, err := strconv.Atoi(v)
+		c.Port = port
+		return err
+	}},
+	{"host-key", "SUDOKU_HOST_KEY_PATH", "path of the SSH host key", func(c *Config, v string) error {
+		c.HostKeyPath = v
+		return nil
+	}},
+	{"data-dir", "SUDOKU_DATA_DIR", "directory for the leaderboard, players and saved games", func(c *Config, v string) error {
+		c.DataDir = v
+		return nil
+	}},
+	{"leaderboard-store", "SUDOKU_LEADERBOARD_STORE", "leaderboard storage backend: json or bolt", func(c *Config, v string) error {
+		c.LeaderboardStore = v
+		return nil
+	}},
+	{"idle-timeout", "SUDOKU_IDLE_TIMEOUT", "disconnect idle sessions after this long, e.g. 30m (0 disables)", func(c *Config, v string) error {
+		d, err := time.ParseDuration(v)
+		c.IdleTimeout = d
+		return err
+	}},
+	{"max-sessions", "SUDOKU_MAX_SESSIONS", "maximum concurrent sessions (0 means unlimited)", func(c *Config, v string) error {
+		n, err := strconv.Atoi(v)
+		c.MaxSessions = n
+		return err
+	}},
+	{"themes-file", "SUDOKU_THEMES_FILE", "TOML file defining extra color themes", func(c *Config, v string) error {
+		c.ThemesFile = v
+		return nil
+	}},
+	{"keymaps-file", "SUDOKU_KEYMAPS_FILE", "TOML file defining extra key maps", func(c *Config, v string) error {
+		c.KeyMapsFile = v
+		return nil
+	}},
+	{"daily-secret", "SUDOKU_DAILY_SECRET", "secret mixed into the daily puzzles' seeds (default: one generated in the data dir)", func(c *Config, v string) error {
+		c.DailySecret = v
+		return nil
+	}},
+	{"admin-password", "SUDOKU_ADMIN_PASSWORD", "password for leaderboard admin mode", func(c *Config, v string) error {
+		c.Admin.Password = v
+		return nil
+	}},
+	{"admin-password-file", "SUDOKU_ADMIN_PASSWORD_FILE", "file holding the password for leaderboard admin mode", func(c *Config, v string) error {
+		c.Admin.PasswordFile = v
+		return nil
+	}},
+}
+
+// LoadConfig builds the configuration from args and the environment and
+// validates it. Every problem found is reported, not just the first.
+func LoadConfig(args []string) (*Config, error) {
+	fs := flag.NewFlagSet("sudoku-tui", flag.ContinueOnError)
+	configFile := fs.String("config", "", "path of a TOML config file (default "+defaultConfigFileName+" if present)")
+	flagValues := make(map[string]*string)
+	for _, s := range settings {
+		flagValues[s.flag] = fs.String(s.flag, "", s.usage+" [$"+s.env+"]")
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("reading .env: %w", err)
+	}
+
+	c := DefaultConfig()
+	path := *configFile
+	if path == "" {
+		path = os.Getenv("SUDOKU_CONFIG")
+	}
+	if path == "" {
+		if _, err := os.Stat(defaultConfigFileName); err == nil {
+			path = defaultConfigFileName
+		}
+	}
+	if path != "" {
+		if _, err := toml.DecodeFile(path, c); err != nil {
+			return nil, fmt.Errorf("reading %s: %w", path, err)
+		}
+	}
+
+	var errs []error
+	for _, s := range settings {
+		if v, ok := os.LookupEnv(s.env); ok {
+			if err := s.apply(c, v); err != nil {
+				errs = append(errs, fmt.Errorf("$%s: %w", s.env, err))
+			}
+		}
+	}
+	fs.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if s.flag == f.Name {
+				if err := s.apply(c, *flagValues[s.flag]); err != nil {
+					errs = append(errs, fmt.Errorf("-%s: %w", s.flag, err))
+				}
+			}
+		}
+	})
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return c, c.Validate()
+}
+
+func (c *Config) Validate() error {
+	var errs []error
+	if strings.TrimSpace(c.Host) == "" {
+		errs = append(errs, errors.New("host must not be empty"))
+	}
+	if c.Port < 1 || c.Port > 65535 {
+		errs = append(errs, fmt.Errorf("port %d is out of range", c.Port))
+	}
+	if c.HostKeyPath == "" {
+		errs = append(errs, errors.New("host key path must not be empty"))
+	}
+	if c.LeaderboardStore != "json" && c.LeaderboardStore != "bolt" {
+		errs = append(errs, fmt.Errorf("leaderboard store must be json or bolt, not %q", c.LeaderboardStore))
+	}
+	if c.IdleTimeout < 0 {
+		errs = append(errs, errors.New("idle timeout must not be negative"))
+	}
+	if c.MaxSessions < 0 {
+		errs = append(errs, errors.New("max sessions must not be negative"))
+	}
+	if info, err := os.Stat(c.DataDir); err == nil && !info.IsDir() {
+		errs = append(errs, fmt.Errorf("data dir %s is not a directory", c.DataDir))
+	} else if err != nil && !os.IsNotExist(err) {
+		errs = append(errs, fmt.Errorf("data dir: %w", err))
+	}
+	if c.ThemesFile != "" {
+		if _, err := os.Stat(c.ThemesFile); err != nil {
+			errs = append(errs, fmt.Errorf("themes file: %w", err))
+		}
+	}
+	if c.KeyMapsFile != "" {
+		if _, err := os.Stat(c.KeyMapsFile); err != nil {
+			errs = append(errs, fmt.Errorf("key maps file: %w", err))
+		}
+	}
+	if c.Admin.PasswordFile != "" {
+		if _, err := os.Stat(c.Admin.PasswordFile); err != nil {
+			errs = append(errs, fmt.Errorf("admin password file: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *Config) Address() string {
+	return net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+}
+
+// dataPath returns the path of name inside the data directory.
+func (c *Config) dataPath(name string) string {
+	return filepath.Join(c.DataDir, name)
+}
+
+// themesPath returns the file extra themes are loaded from.
+func (c *Config) themesPath() string {
+	if c.ThemesFile != "" {
+		return c.ThemesFile
+	}
+	return c.dataPath(themesFileName)
+}
+
+// keyMapsPath returns the file extra key maps are loaded from.
+func (c *Config) keyMapsPath() string {
+	if c.KeyMapsFile != "" {
+		return c.KeyMapsFile
+	}
+	return c.dataPath(keyMapsFileName)
+}
+
+// adminPassword returns the configured admin password, or "" if admin mode
+// is disabled.
+func (c *Config) adminPassword() string {
+	if c.Admin.Password != "" {
+		return strings.TrimSpace(c.Admin.Password)
+	}
+	path := c.Admin.PasswordFile
+	if path == "" {
+		var err error
+		if path, err = findAdminPasswordFile(); err != nil {
+			return ""
+		}
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(content))
+}
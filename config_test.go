@@ -0,0 +1,101 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// isolateConfig runs the test in an empty directory with none of the
+// configuration's environment variables set, restoring both afterwards.
+func isolateConfig(t *testing.T) {
+	t.Helper()
+	names := []string{"SUDOKU_CONFIG"}
+	for _, s := range settings {
+		names = append(names, s.env)
+	}
+	for _, name := range names {
+		// Setenv restores the variable when the test ends, which also undoes
+		// anything the .env file sets.
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func writeTestFile(t *testing.T, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Each layer sets one more value than the layer above it, so every value
+// shows which layer won.
+func TestLoadConfigPrecedence(t *testing.T) {
+	isolateConfig(t)
+	// $SUDOKU_CONFIG replaces sudoku.toml, which would fail to parse.
+	writeTestFile(t, defaultConfigFileName, "not toml")
+	writeTestFile(t, "server.toml", `
+host = "toml.example"
+port = 1001
+data_dir = "toml"
+idle_timeout = "5m"
+max_sessions = 1
+`)
+	t.Setenv("SUDOKU_CONFIG", "server.toml")
+	writeTestFile(t, ".env", "SUDOKU_PORT=1002\nSUDOKU_DATA_DIR=dotenv\nSUDOKU_MAX_SESSIONS=2\n")
+	t.Setenv("SUDOKU_DATA_DIR", "env")
+	t.Setenv("SUDOKU_MAX_SESSIONS", "3")
+
+	c, err := LoadConfig([]string{"-max-sessions", "4"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := DefaultConfig()
+	want.Host = "toml.example"
+	want.IdleTimeout = 5 * time.Minute
+	want.Port = 1002
+	want.DataDir = "env"
+	want.MaxSessions = 4
+	if *c != *want {
+		t.Errorf("LoadConfig = %+v\nwant %+v", *c, *want)
+	}
+}
+
+func TestLoadConfigReportsEveryError(t *testing.T) {
+	isolateConfig(t)
+	t.Setenv("SUDOKU_PORT", "many")
+	_, err := LoadConfig([]string{"-idle-timeout", "soon"})
+	if err == nil || !strings.Contains(err.Error(), "$SUDOKU_PORT") || !strings.Contains(err.Error(), "-idle-timeout") {
+		t.Errorf("LoadConfig error %q; want both bad values named", err)
+	}
+
+	// Values that parse but aren't allowed are also reported together.
+	os.Unsetenv("SUDOKU_PORT")
+	_, err = LoadConfig([]string{"-port", "70000", "-leaderboard-store", "csv", "-max-sessions", "-1"})
+	for _, want := range []string{"port 70000", "csv", "max sessions"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("LoadConfig error %q; want it to mention %q", err, want)
+		}
+	}
+}
+
+func TestLoadConfigMissingFile(t *testing.T) {
+	isolateConfig(t)
+	if _, err := LoadConfig([]string{"-config", "missing.toml"}); err == nil {
+		t.Error("LoadConfig ignored a config file that doesn't exist")
+	}
+	// Without one named, a missing sudoku.toml just means the defaults.
+	if c, err := LoadConfig(nil); err != nil || *c != *DefaultConfig() {
+		t.Errorf("LoadConfig(nil) = %+v, %v; want the defaults", c, err)
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// cellEdit is a cell's new digit on a co-op board, 0 to clear it.
+type cellEdit struct {
+	coordinate
+	value int
+	// undo marks an edit taking back the session's own write of undone.
+	undo   bool
+	undone int
+}
+
+// Edit writes to the board of session's co-op game and returns its new
+// state. The last write to a cell wins and is credited to its writer, except
+// that an undo leaves alone a cell someone else has written since.
+func (h *RoomHub) Edit(session *Session, edits []cellEdit) (roomStatus, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	r, _ := h.running(session)
+	if r == nil || r.mode != coopMode || r.solved {
+		return roomStatus{}, false
+	}
+	for _, e := range edits {
+		if r.puzzle[e.row][e.col] != 0 {
+			continue
+		}
+		if e.undo && (r.board[e.row][e.col] != e.undone ||
+			e.undone != 0 && r.owners[e.row][e.col] != session) {
+			continue
+		}
+		r.board[e.row][e.col] = e.value
+		r.owners[e.row][e.col] = nil
+		if e.value != 0 {
+			r.owners[e.row][e.col] = session
+		}
+	}
+	r.solved = r.board == r.solution
+	h.notify(r)
+	return r.status(), true
+}
+
+// Move records where session's cursor is on its co-op board, so that the
+// other players can see it.
+func (h *RoomHub) Move(session *Session, c coordinate) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	r, m := h.running(session)
+	if r == nil || r.mode != coopMode || m.cursor == c {
+		return
+	}
+	m.cursor = c
+	h.notify(r)
+}
+
+// NewCoopGameModel starts a player's view of their room's shared board. The
+// solve is shared, so it isn't ranked.
+func NewCoopGameModel(width, height int, session *Session, status roomStatus) *GameModel {
+	m := newGameModel(width, height, status.difficulty, status.puzzle, status.solution, session)
+	m.seed = status.seed
+	m.room = &status
+	m.unranked = "it was solved together"
+	m.menuOptions[1] = "Leave Game"
+	m.syncCoopBoard(status)
+	m.shareCursor()
+	return m
+}
+
+// shareCoopMove sends the digits of a move to the shared board, and takes
+// the board back right away so that older states still on their way are
+// ignored. Cells an undo skips because a partner has written them since
+// are put back on the player's board by that same update.
+func (m *GameModel) shareCoopMove(mv move, forward bool) {
+	var edits []cellEdit
+	for _, c := range mv {
+		if c.oldValue == c.newValue {
+			continue
+		}
+		if forward {
+			edits = append(edits, cellEdit{coordinate: c.coordinate, value: c.newValue})
+		} else {
+			edits = append(edits, cellEdit{coordinate: c.coordinate, value: c.oldValue, undo: true, undone: c.newValue})
+		}
+	}
+	if len(edits) == 0 {
+		return
+	}
+	if status, ok := roomHub.Edit(m.session, edits); ok {
+		m.updateRoom(status)
+	}
+}
+
+func (m GameModel) shareCursor() {
+	if m.inRoom(coopMode) {
+		roomHub.Move(m.session, m.cursor)
+	}
+}
+
+// syncCoopBoard replaces the player's board with the shared one. The
+// player's notes are kept except where a digit was placed.
+func (m *GameModel) syncCoopBoard(status roomStatus) {
+	if m.state == Won || m.state == Lost {
+		return
+	}
+	if m.board != status.board {
+		for i := 0; i < sudokuLen; i++ {
+			for j := 0; j < sudokuLen; j++ {
+				if status.board[i][j] != 0 {
+					m.notes[i][j] = 0
+				}
+			}
+		}
+		m.board = status.board
+		if m.state == Playing || m.state == NeedsCorrection {
+			m.refreshState()
+		} else {
+			// The player is away from the board; it's checked once they
+			// edit it again.
+			m.cellsLeft = 0
+			for i := 0; i < sudokuLen; i++ {
+				for j := 0; j < sudokuLen; j++ {
+					if m.board[i][j] == 0 {
+						m.cellsLeft++
+					}
+				}
+			}
+		}
+	}
+	// Whoever placed the last digit, everyone has solved the board.
+	if status.solved && m.state != Won {
+		m.state = Won
+		m.elapsedTimeOnWin = m.elapsed()
+		m.pauseClock()
+	}
+}
+
+// partnerCursors returns the cells the other players' cursors are on, in
+// their colors.
+func (m GameModel) partnerCursors() map[coordinate]lipgloss.Color {
+	if !m.inRoom(coopMode) {
+		return nil
+	}
+	cursors := map[coordinate]lipgloss.Color{}
+	for i, p := range m.room.members {
+		if p.session != m.session && !p.left {
+			cursors[p.cursor] = m.theme.partnerCursor(i)
+		}
+	}
+	return cursors
+}
+
+// renderCoopPanel lists the players of a co-op game in their cursor colors
+// with the number of cells each has filled.
+func (m GameModel) renderCoopPanel() string {
+	var s strings.Builder
+	s.WriteString(m.theme.textStyle().Bold(true).Render("Co-op "+m.room.code) + "\n\n")
+	for i, p := range m.room.members {
+		color := m.theme.partnerCursor(i)
+		if p.session == m.session {
+			color = m.theme.Cursor
+		}
+		status := fmt.Sprintf("%d cells", p.owned)
+		if p.left {
+			status += ", left"
+		}
+		s.WriteString(fmt.Sprintf("%s %s\n  %s\n",
+			lipgloss.NewStyle().Background(color).Render("  "),
+			m.theme.textStyle().Render(truncateString(p.name, 16)),
+			m.theme.textStyle().Render(status)))
+	}
+	return s.String()
+}
+
+// coopCredits lists everyone who filled cells of the solved board, most
+// cells first.
+func (m GameModel) coopCredits() string {
+	members := append([]memberStatus(nil), m.room.members...)
+	sort.SliceStable(members, func(i, j int) bool { return members[i].owned > members[j].owned })
+	var credits []string
+	for _, p := range members {
+		if p.owned > 0 {
+			credits = append(credits, fmt.Sprintf("%s (%d)", p.name, p.owned))
+		}
+	}
+	return "Solved together by " + strings.Join(credits, ", ")
+}
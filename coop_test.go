@@ -0,0 +1,125 @@
+package main
+
+import "testing"
+
+// startCoop starts a co-op room for the given players, hosted by the first.
+func startCoop(t *testing.T, h *RoomHub, players ...*Session) roomStatus {
+	t.Helper()
+	status := h.Create(players[0], coopMode, Easy)
+	for _, s := range players[1:] {
+		if _, err := h.Join(s, status.code); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := h.Start(players[0]); err != nil {
+		t.Fatal(err)
+	}
+	return status
+}
+
+// emptyCell returns the first cell of the puzzle without a given.
+func emptyCell(puzzle [sudokuLen][sudokuLen]int) coordinate {
+	for i := 0; i < sudokuLen; i++ {
+		for j := 0; j < sudokuLen; j++ {
+			if puzzle[i][j] == 0 {
+				return coordinate{i, j}
+			}
+		}
+	}
+	return coordinate{-1, -1}
+}
+
+func TestCoopEditLastWriterWins(t *testing.T) {
+	h := NewRoomHub()
+	ann, bob := &Session{Username: "ann"}, &Session{Username: "bob"}
+	c := emptyCell(startCoop(t, h, ann, bob).puzzle)
+
+	h.Edit(ann, []cellEdit{{coordinate: c, value: 3}})
+	status, ok := h.Edit(bob, []cellEdit{{coordinate: c, value: 7}})
+	if !ok || status.board[c.row][c.col] != 7 || status.owners[c.row][c.col] != bob {
+		t.Fatalf("after bob overwrote ann's 3 the cell holds %d by %v", status.board[c.row][c.col], status.owners[c.row][c.col])
+	}
+	if status.members[0].owned != 0 || status.members[1].owned != 1 {
+		t.Errorf("ann is credited with %d cells and bob %d; want 0 and 1", status.members[0].owned, status.members[1].owned)
+	}
+
+	// Clearing a cell takes the credit away, whoever wrote it.
+	status, _ = h.Edit(ann, []cellEdit{{coordinate: c}})
+	if status.board[c.row][c.col] != 0 || status.owners[c.row][c.col] != nil {
+		t.Error("clearing the cell left its digit or writer behind")
+	}
+}
+
+// An undo only takes back the player's own write. If a partner has written
+// the cell since, their digit stays.
+func TestCoopUndoSkipsOverwrittenCells(t *testing.T) {
+	h := NewRoomHub()
+	ann, bob := &Session{Username: "ann"}, &Session{Username: "bob"}
+	puzzle := startCoop(t, h, ann, bob).puzzle
+	c := emptyCell(puzzle)
+
+	h.Edit(ann, []cellEdit{{coordinate: c, value: 3}})
+	h.Edit(bob, []cellEdit{{coordinate: c, value: 7}})
+	status, _ := h.Edit(ann, []cellEdit{{coordinate: c, undo: true, undone: 3}})
+	if status.board[c.row][c.col] != 7 || status.owners[c.row][c.col] != bob {
+		t.Fatal("ann's undo took away bob's digit")
+	}
+
+	// Nor can an undo bring back a digit over one written after it was
+	// cleared.
+	h.Edit(ann, []cellEdit{{coordinate: c}})
+	h.Edit(bob, []cellEdit{{coordinate: c, value: 5}})
+	status, _ = h.Edit(ann, []cellEdit{{coordinate: c, value: 7, undo: true}})
+	if status.board[c.row][c.col] != 5 {
+		t.Fatal("undoing a clear overwrote the digit written since")
+	}
+
+	// With nobody else writing in between, undo works as usual.
+	status, _ = h.Edit(bob, []cellEdit{{coordinate: c, undo: true, undone: 5}})
+	if status.board[c.row][c.col] != 0 || status.owners[c.row][c.col] != nil {
+		t.Error("an undo with no write in between did nothing")
+	}
+}
+
+func TestCoopEditIgnoresGivens(t *testing.T) {
+	h := NewRoomHub()
+	ann := &Session{Username: "ann"}
+	puzzle := startCoop(t, h, ann).puzzle
+	var given coordinate
+	for puzzle[given.row][given.col] == 0 {
+		given.col++
+	}
+	status, _ := h.Edit(ann, []cellEdit{{coordinate: given, value: puzzle[given.row][given.col]%9 + 1}})
+	if status.board != puzzle {
+		t.Error("an edit changed a given")
+	}
+}
+
+func TestCoopSolvedBoardTakesNoMoreEdits(t *testing.T) {
+	h := NewRoomHub()
+	ann, bob := &Session{Username: "ann"}, &Session{Username: "bob"}
+	start := startCoop(t, h, ann, bob)
+
+	var edits []cellEdit
+	for i := 0; i < sudokuLen; i++ {
+		for j := 0; j < sudokuLen; j++ {
+			if start.puzzle[i][j] == 0 {
+				edits = append(edits, cellEdit{coordinate: coordinate{i, j}, value: start.solution[i][j]})
+			}
+		}
+	}
+	if status, _ := h.Edit(bob, edits); !status.solved {
+		t.Fatal("the solved board wasn't marked solved")
+	}
+	if _, ok := h.Edit(ann, []cellEdit{{coordinate: edits[0].coordinate}}); ok {
+		t.Error("a solved board was edited")
+	}
+
+	// Race rooms have no shared board to edit.
+	cat := &Session{Username: "cat"}
+	h.Create(cat, raceMode, Easy)
+	h.Start(cat)
+	if _, ok := h.Edit(cat, edits); ok {
+		t.Error("a race room took an edit")
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+const (
+	dailyLeaderboardName = "sudoku_daily_leaderboard"
+	dailyAttemptsName    = "sudoku_daily_attempts.json"
+	dailySecretName      = "sudoku_daily_secret"
+	dailyDateFormat      = "2006-01-02"
+)
+
+// dailyLeaderboardService ranks daily puzzle times. It's opened in main.
+var dailyLeaderboardService *LeaderboardService
+
+// today is the current daily puzzle's date. Days roll over at midnight UTC
+// so everyone shares the same puzzle regardless of time zone.
+func today() string {
+	return time.Now().UTC().Format(dailyDateFormat)
+}
+
+type dailyKey struct {
+	day        string
+	difficulty Difficulty
+}
+
+var (
+	dailyPuzzlesMu sync.Mutex
+	dailyPuzzles   = map[dailyKey][2][sudokuLen][sudokuLen]int{}
+)
+
+// dailySecret keys the daily seeds. Without it anyone could derive a day's
+// seed from its date and solve the puzzle before it's released.
+var dailySecret string
+
+// LoadDailySecretFromFile reads the secret kept in filename, generating it
+// the first time the server starts.
+func LoadDailySecretFromFile(filename string) (string, error) {
+	data, err := os.ReadFile(filename)
+	if err == nil {
+		secret := strings.TrimSpace(string(data))
+		if secret == "" {
+			return "", fmt.Errorf("%s is empty", filename)
+		}
+		return secret, nil
+	}
+	if !os.IsNotExist(err) {
+		return "", err
+	}
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	secret := hex.EncodeToString(b)
+	return secret, writeFileAtomic(filename, []byte(secret+"\n"), 0600)
+}
+
+// dailySeed derives the seed of a day's puzzle from its date and the
+// server's secret, so every player gets the same grid but nobody can
+// generate it ahead of time.
+func dailySeed(day string, difficulty Difficulty) int64 {
+	h := hmac.New(sha256.New, []byte(dailySecret))
+	fmt.Fprintf(h, "daily/%s/%s", day, difficulty)
+	return int64(binary.BigEndian.Uint64(h.Sum(nil)) & (1<<seedBits - 1))
+}
+
+// generateDailySudoku returns the puzzle for a day and difficulty. Puzzles
+// are cached since the harder ones take a moment to generate.
+func generateDailySudoku(day string, difficulty Difficulty) ([sudokuLen][sudokuLen]int, [sudokuLen][sudokuLen]int) {
+	dailyPuzzlesMu.Lock()
+	defer dailyPuzzlesMu.Unlock()
+
+	key := dailyKey{day, difficulty}
+	if p, ok := dailyPuzzles[key]; ok {
+		return p[0], p[1]
+	}
+	board, solution := generateSudoku(dailySeed(day, difficulty), difficulty)
+	dailyPuzzles[key] = [2][sudokuLen][sudokuLen]int{board, solution}
+	return board, solution
+}
+
+// NewDailyGameModel starts today's puzzle. Only a player's first attempt at
+// each daily puzzle is ranked; later attempts can be played for fun.
+func NewDailyGameModel(width, height int, difficulty Difficulty, session *Session) *GameModel {
+	day := today()
+	board, solution := generateDailySudoku(day, difficulty)
+	m := newGameModel(width, height, difficulty, board, solution, session)
+	// The seed stays hidden: sharing it would let others play the puzzle
+	// ahead of their ranked attempt.
+	m.daily = day
+	m.leaderboard = dailyLeaderboardService
+	ranked, err := dailyAttempts.Start(session.PlayerID, day, difficulty)
+	if err != nil {
+		log.Error("could not save daily attempts", "error", err)
+	}
+	if !ranked {
+		m.unranked = "you already played this daily puzzle"
+	}
+	return m
+}
+
+// DailyAttempts records which players have started which daily puzzles.
+type DailyAttempts struct {
+	mu       sync.Mutex
+	filename string
+	Started  map[string]bool `json:"started"`
+}
+
+var dailyAttempts = NewDailyAttempts(dailyAttemptsName)
+
+func NewDailyAttempts(filename string) *DailyAttempts {
+	return &DailyAttempts{
+		filename: filename,
+		Started:  map[string]bool{},
+	}
+}
+
+func LoadDailyAttemptsFromFile(filename string) (*DailyAttempts, error) {
+	attempts := NewDailyAttempts(filename)
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return attempts, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, attempts); err != nil {
+		return nil, err
+	}
+	if attempts.Started == nil {
+		attempts.Started = map[string]bool{}
+	}
+	return attempts, nil
+}
+
+// Start records that the player started a daily puzzle and reports whether
+// this is their first, ranked, attempt at it.
+func (a *DailyAttempts) Start(playerID, day string, difficulty Difficulty) (bool, error) {
+	key := fmt.Sprintf("%s/%s/%s", day, difficulty, playerID)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.Started[key] {
+		return false, nil
+	}
+	a.Started[key] = true
+	data, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return true, err
+	}
+	return true, writeFileAtomic(a.filename, data, 0644)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadDailySecretFromFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), dailySecretName)
+	secret, err := LoadDailySecretFromFile(filename)
+	if err != nil || len(secret) < 32 {
+		t.Fatalf("LoadDailySecretFromFile generated %q, %v", secret, err)
+	}
+	if info, err := os.Stat(filename); err != nil || info.Mode().Perm()&0o077 != 0 {
+		t.Errorf("the secret file is readable by others: %v, %v", info.Mode(), err)
+	}
+	// A restarted server must keep the day's puzzles.
+	if again, err := LoadDailySecretFromFile(filename); err != nil || again != secret {
+		t.Errorf("reloading gave %q, %v; want %q", again, err, secret)
+	}
+	if other, _ := LoadDailySecretFromFile(filepath.Join(t.TempDir(), dailySecretName)); other == secret {
+		t.Error("two servers generated the same secret")
+	}
+
+	os.WriteFile(filename, []byte("\n"), 0o600)
+	if _, err := LoadDailySecretFromFile(filename); err == nil {
+		t.Error("an empty secret was accepted")
+	}
+}
+
+func TestDailySeedNeedsSecret(t *testing.T) {
+	old := dailySecret
+	t.Cleanup(func() { dailySecret = old })
+
+	dailySecret = "one"
+	seed := dailySeed("2026-10-16", Hard)
+	if dailySeed("2026-10-16", Hard) != seed {
+		t.Fatal("the same day's seed changed")
+	}
+	if dailySeed("2026-10-17", Hard) == seed || dailySeed("2026-10-16", Medium) == seed {
+		t.Error("two daily puzzles share a seed")
+	}
+	dailySecret = "two"
+	if dailySeed("2026-10-16", Hard) == seed {
+		t.Error("the seed doesn't depend on the secret")
+	}
+}
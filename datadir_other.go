@@ -0,0 +1,16 @@
+//go:build !unix
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+const dataDirLockName = "sudoku.lock"
+
+// lockDataDir only creates the lock file on systems without flock, so two
+// processes sharing a data dir aren't stopped there.
+func lockDataDir(dir string) (*os.File, error) {
+	return os.OpenFile(filepath.Join(dir, dataDirLockName), os.O_CREATE|os.O_RDWR, 0644)
+}
@@ -0,0 +1,33 @@
+//go:build unix
+
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+const dataDirLockName = "sudoku.lock"
+
+var errDataDirInUse = errors.New("another sudoku-tui is using the data dir; stop it, or play locally with a different -data-dir")
+
+// lockDataDir locks the data dir for as long as the returned file stays
+// open. The stores rewrite whole files or lock them themselves, so a local
+// game and a server sharing a data dir would overwrite each other's scores
+// or fail to start; whichever comes second is refused instead.
+func lockDataDir(dir string) (*os.File, error) {
+	f, err := os.OpenFile(filepath.Join(dir, dataDirLockName), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, errDataDirInUse
+		}
+		return nil, err
+	}
+	return f, nil
+}
@@ -0,0 +1,31 @@
+//go:build unix
+
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLockDataDir(t *testing.T) {
+	dir := t.TempDir()
+	lock, err := lockDataDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := lockDataDir(dir); !errors.Is(err, errDataDirInUse) {
+		t.Fatalf("a second process locked the data dir in use: %v", err)
+	}
+	other, err := lockDataDir(t.TempDir())
+	if err != nil {
+		t.Fatalf("a different data dir couldn't be locked: %v", err)
+	}
+	other.Close()
+
+	lock.Close()
+	again, err := lockDataDir(dir)
+	if err != nil {
+		t.Fatalf("the data dir stayed locked after it was released: %v", err)
+	}
+	again.Close()
+}
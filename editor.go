@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// EditorModel lets the player type in the givens of a puzzle, say from a
+// newspaper, and then play it.
+type EditorModel struct {
+	// grid holds the givens as both its board and initial board, so the
+	// usual board rendering shows them as givens.
+	grid          GameModel
+	solutions     int
+	err           string
+	width, height int
+	session       *Session
+	help          help.Model
+}
+
+func NewEditorModel(width, height int, session *Session) *EditorModel {
+	m := &EditorModel{
+		grid: GameModel{
+			KeyMap:                  session.KeyMap(),
+			remainingErrCoordinates: make(map[coordinate]bool),
+			highlightPeers:          session.Settings.HighlightPeers,
+			highlightMatches:        session.Settings.HighlightMatches,
+			theme:                   session.Theme(),
+		},
+		width:   width,
+		height:  height,
+		session: session,
+		help:    newHelp(session.Theme(), width),
+	}
+	m.check()
+	return m
+}
+
+func (m EditorModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m EditorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		m.err = ""
+		switch {
+		case key.Matches(msg, m.grid.KeyMap.ForceQuit):
+			return m, tea.Quit
+		case key.Matches(msg, m.grid.KeyMap.Back):
+			return NewMenuModel(m.width, m.height, m.session), nil
+		case key.Matches(msg, m.grid.KeyMap.Select):
+			solution, err := validatePuzzle(m.grid.board)
+			if err != nil {
+				m.err = err.Error()
+				return m, nil
+			}
+			model := newCustomGameModel(m.width, m.height, m.grid.board, solution, m.session)
+			return model, model.Init()
+		case key.Matches(msg, m.grid.KeyMap.Up):
+			m.grid.cursorUp()
+		case key.Matches(msg, m.grid.KeyMap.Down):
+			m.grid.cursorDown()
+		case key.Matches(msg, m.grid.KeyMap.Left):
+			m.grid.cursorLeft()
+		case key.Matches(msg, m.grid.KeyMap.Right):
+			m.grid.cursorRight()
+		case key.Matches(msg, m.grid.KeyMap.Number):
+			if digit, ok := m.grid.KeyMap.digit(msg); ok {
+				m.setGiven(digit)
+			}
+		case key.Matches(msg, m.grid.KeyMap.Clear):
+			m.setGiven(0)
+		case key.Matches(msg, m.grid.KeyMap.Help):
+			m.help.ShowAll = !m.help.ShowAll
+		}
+	case tea.MouseMsg:
+		if !isLeftClick(msg) {
+			return m, nil
+		}
+		_, board := m.layout()
+		if c, ok := boardCellAt(msg.X-board.col, msg.Y-board.row, false); ok {
+			m.grid.cursor = c
+		}
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.help.Width = msg.Width
+	}
+	return m, nil
+}
+
+func (m *EditorModel) setGiven(value int) {
+	c := m.grid.cursor
+	m.grid.board[c.row][c.col] = value
+	m.grid.initialBoard[c.row][c.col] = value
+	m.check()
+}
+
+// check recomputes the conflicts and, when there are none, how many
+// solutions the givens have. Counting stops at two, which is enough to tell
+// a proper puzzle apart.
+func (m *EditorModel) check() {
+	m.grid.remainingErrCoordinates = findConflicts(m.grid.board)
+	m.solutions = 0
+	if len(m.grid.remainingErrCoordinates) == 0 {
+		m.solutions = countSolutions(m.grid.board)
+	}
+}
+
+func (m EditorModel) View() string {
+	view, _ := m.layout()
+	return view
+}
+
+// layout renders the editor and reports the top-left corner of its board.
+func (m EditorModel) layout() (string, coordinate) {
+	givens := 0
+	for i := 0; i < sudokuLen; i++ {
+		for j := 0; j < sudokuLen; j++ {
+			if m.grid.board[i][j] != 0 {
+				givens++
+			}
+		}
+	}
+
+	errStyle := m.grid.theme.failureStyle()
+	var status string
+	switch {
+	case len(m.grid.remainingErrCoordinates) > 0:
+		status = errStyle.Render("Conflicts: a digit is repeated in a row, column or box")
+	case m.solutions == 0:
+		status = errStyle.Render("Solutions: none")
+	case m.solutions == 1:
+		status = m.grid.theme.successStyle().Render("Solutions: unique")
+	default:
+		status = "Solutions: multiple"
+	}
+
+	var s strings.Builder
+	s.WriteString(fmt.Sprintf("Givens: %d\n", givens))
+	s.WriteString(status + "\n\n")
+	k := m.grid.KeyMap
+	s.WriteString(m.help.View(bindingHelp{
+		k.move(), relabel(k.Number, "set given"), k.Clear,
+		relabel(k.Select, "play this puzzle"), relabel(k.Back, "back to menu"), k.Help,
+	}))
+	if m.err != "" {
+		s.WriteString("\n\n" + errStyle.Render(m.err))
+	}
+
+	header := lipgloss.NewStyle().Bold(true).Render("Create puzzle")
+	boardView := m.grid.renderBoard()
+	mainView := lipgloss.JoinVertical(lipgloss.Center, header, "", boardView, s.String())
+
+	mainWidth := lipgloss.Width(mainView)
+	board := coordinate{
+		row: placeOffset(m.height, lipgloss.Height(mainView)) + 2,
+		col: placeOffset(m.width, mainWidth) + joinOffset(mainWidth, lipgloss.Width(boardView)),
+	}
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, mainView), board
+}
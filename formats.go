@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// puzzleJSON is the JSON puzzle format. Both grids are 81-character lines
+// read row by row, using "." for empty cells.
+type puzzleJSON struct {
+	Puzzle   string `json:"puzzle"`
+	Progress string `json:"progress,omitempty"`
+}
+
+// parsePuzzle reads a puzzle in any of the supported formats: an
+// 81-character line, the .sdk (SadMan) grid format, or puzzleJSON. progress
+// is the givens plus any digits already filled in, and equals givens for
+// formats that only hold the puzzle.
+func parsePuzzle(text string) (givens, progress [sudokuLen][sudokuLen]int, err error) {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "{") {
+		// Some terminals copy a long line they wrapped as several, which
+		// breaks the grids across lines. JSON never needs a line break, so
+		// drop them.
+		text = strings.NewReplacer("\r", "", "\n", "").Replace(text)
+		var p puzzleJSON
+		if err := json.Unmarshal([]byte(text), &p); err != nil {
+			return givens, progress, fmt.Errorf("invalid JSON: %w", err)
+		}
+		if givens, err = parseGrid(p.Puzzle); err != nil {
+			return givens, progress, err
+		}
+		if p.Progress == "" {
+			return givens, givens, nil
+		}
+		if progress, err = parseGrid(p.Progress); err != nil {
+			return givens, progress, fmt.Errorf("progress: %w", err)
+		}
+		for i := 0; i < sudokuLen; i++ {
+			for j := 0; j < sudokuLen; j++ {
+				if givens[i][j] != 0 && progress[i][j] != givens[i][j] {
+					return givens, progress, errors.New("progress doesn't match the puzzle's givens")
+				}
+			}
+		}
+		return givens, progress, nil
+	}
+
+	// Drop .sdk comment lines and section headers, then read what's left
+	// as a plain grid.
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "[") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	givens, err = parseGrid(strings.Join(lines, "\n"))
+	return givens, givens, err
+}
+
+// parseGrid reads 81 cells, taking digits as givens and "." or "0" as
+// empty cells. Whitespace and grid drawing characters are ignored.
+func parseGrid(text string) ([sudokuLen][sudokuLen]int, error) {
+	var grid [sudokuLen][sudokuLen]int
+	n := 0
+	for _, r := range text {
+		var value int
+		switch {
+		case r >= '1' && r <= '9':
+			value = int(r - '0')
+		case r == '.' || r == '0':
+		case strings.ContainsRune(" \t\r\n|-+", r):
+			continue
+		default:
+			return grid, fmt.Errorf("unexpected character %q", r)
+		}
+		if n == sudokuLen*sudokuLen {
+			return grid, errors.New("more than 81 cells")
+		}
+		grid[n/sudokuLen][n%sudokuLen] = value
+		n++
+	}
+	if n != sudokuLen*sudokuLen {
+		return grid, fmt.Errorf("found %d cells, expected 81", n)
+	}
+	return grid, nil
+}
+
+// validatePuzzle checks that givens follow the rules and have exactly one
+// solution, which it returns.
+func validatePuzzle(givens [sudokuLen][sudokuLen]int) ([sudokuLen][sudokuLen]int, error) {
+	if len(findConflicts(givens)) > 0 {
+		return givens, errors.New("the puzzle repeats a digit in a row, column or box")
+	}
+	switch countSolutions(givens) {
+	case 0:
+		return givens, errors.New("the puzzle has no solution")
+	case 1:
+	default:
+		return givens, errors.New("the puzzle has more than one solution")
+	}
+	solution, _ := solveSudoku(givens)
+	return solution, nil
+}
+
+// findConflicts returns the filled cells whose digit is repeated elsewhere
+// in their row, column or box.
+func findConflicts(board [sudokuLen][sudokuLen]int) map[coordinate]bool {
+	conflicts := make(map[coordinate]bool)
+	for i := 0; i < sudokuLen; i++ {
+		for j := 0; j < sudokuLen; j++ {
+			if board[i][j] == 0 {
+				continue
+			}
+			for _, p := range peers[i][j] {
+				if board[p.row][p.col] == board[i][j] {
+					conflicts[coordinate{i, j}] = true
+					break
+				}
+			}
+		}
+	}
+	return conflicts
+}
+
+// formatLine writes a grid as an 81-character line.
+func formatLine(grid [sudokuLen][sudokuLen]int) string {
+	var s strings.Builder
+	for i := 0; i < sudokuLen; i++ {
+		for j := 0; j < sudokuLen; j++ {
+			if grid[i][j] == 0 {
+				s.WriteByte('.')
+			} else {
+				s.WriteByte(byte('0' + grid[i][j]))
+			}
+		}
+	}
+	return s.String()
+}
+
+// formatSDK writes a grid in the .sdk format, one row per line.
+func formatSDK(grid [sudokuLen][sudokuLen]int) string {
+	line := formatLine(grid)
+	var rows []string
+	for i := 0; i < sudokuLen; i++ {
+		rows = append(rows, line[i*sudokuLen:(i+1)*sudokuLen])
+	}
+	return strings.Join(rows, "\n")
+}
+
+// formatJSON writes the puzzle and the progress made on it as indented
+// puzzleJSON.
+func formatJSON(givens, progress [sudokuLen][sudokuLen]int) string {
+	data, _ := json.MarshalIndent(puzzleJSON{Puzzle: formatLine(givens), Progress: formatLine(progress)}, "", "  ")
+	return string(data)
+}
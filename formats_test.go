@@ -0,0 +1,127 @@
+package main
+
+import (
+	"maps"
+	"strings"
+	"testing"
+)
+
+// A .sdk file as saved on Windows: CRLF line endings, comments with digits
+// in them, a section header, grid lines and 0 for empty cells.
+const testSDKFile = "#Aanonymous\r\n" +
+	"#D Puzzle 17, rated 3 of 5\r\n" +
+	"[Puzzle]\r\n" +
+	"530|070|000\r\n" +
+	"600|195|000\r\n" +
+	"098|000|060\r\n" +
+	"---+---+---\r\n" +
+	"800|060|003\r\n" +
+	"400|803|001\r\n" +
+	"700|020|006\r\n" +
+	"---+---+---\r\n" +
+	"060|000|280\r\n" +
+	"000|419|005\r\n" +
+	"000|080|079\r\n" +
+	"\r\n"
+
+func TestParseSDKFile(t *testing.T) {
+	givens, progress, err := parsePuzzle(testSDKFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := testGrid(t, testPuzzle); givens != want || progress != want {
+		t.Errorf("parsed %s; want %s", formatLine(givens), testPuzzle)
+	}
+}
+
+func TestExportsParseBack(t *testing.T) {
+	givens := testGrid(t, testPuzzle)
+	progress := givens
+	progress[0][2], progress[8][0] = 4, 3
+
+	for _, export := range []string{formatLine(givens), formatSDK(givens)} {
+		if g, p, err := parsePuzzle(export); err != nil || g != givens || p != givens {
+			t.Errorf("parsePuzzle(%q) = %s, %s, %v", export, formatLine(g), formatLine(p), err)
+		}
+	}
+	// Only JSON keeps the digits the player filled in.
+	if g, p, err := parsePuzzle(formatJSON(givens, progress)); err != nil || g != givens || p != progress {
+		t.Errorf("JSON came back as %s, %s, %v", formatLine(g), formatLine(p), err)
+	}
+	if _, p, err := parsePuzzle(`{"puzzle": "` + testPuzzle + `"}`); err != nil || p != givens {
+		t.Errorf("JSON without progress came back as %s, %v; want the givens", formatLine(p), err)
+	}
+}
+
+// A JSON export copied from a narrow terminal comes back with line breaks
+// in the middle of its grids.
+func TestParseWrappedJSON(t *testing.T) {
+	givens := testGrid(t, testPuzzle)
+	progress := givens
+	progress[0][2] = 4
+	export := formatJSON(givens, progress)
+	wrapped := ""
+	for len(export) > 30 {
+		wrapped += export[:30] + "\r\n"
+		export = export[30:]
+	}
+	wrapped += export
+	if g, p, err := parsePuzzle(wrapped); err != nil || g != givens || p != progress {
+		t.Errorf("parsePuzzle(%q) = %s, %s, %v", wrapped, formatLine(g), formatLine(p), err)
+	}
+}
+
+func TestParsePuzzleRejects(t *testing.T) {
+	changedGiven := "1" + testPuzzle[1:]
+	for _, text := range []string{
+		testPuzzle[:80],
+		testPuzzle + "1",
+		"x" + testPuzzle[1:],
+		`{"puzzle": `,
+		`{"puzzle": "` + testPuzzle + `", "progress": "` + changedGiven + `"}`,
+	} {
+		if _, _, err := parsePuzzle(text); err == nil {
+			t.Errorf("parsePuzzle(%q) succeeded", text)
+		}
+	}
+}
+
+func TestValidatePuzzle(t *testing.T) {
+	solution, err := validatePuzzle(testGrid(t, testPuzzle))
+	if err != nil || solution != testGrid(t, testSolution) {
+		t.Fatalf("validatePuzzle = %s, %v; want the solution", formatLine(solution), err)
+	}
+
+	// No digit repeats, but nothing fits the top right corner: the row
+	// has 1-8 and its column a 9.
+	noSolution := "12345678." + strings.Repeat(".", 27) + "........9" + strings.Repeat(".", 36)
+	// Emptying the bottom row of a unique puzzle leaves it with several.
+	several := testPuzzle[:72] + strings.Repeat(".", 9)
+	for puzzle, want := range map[string]string{
+		"55" + testPuzzle[2:]: "the puzzle repeats a digit in a row, column or box",
+		noSolution:            "the puzzle has no solution",
+		several:               "the puzzle has more than one solution",
+	} {
+		if _, err := validatePuzzle(testGrid(t, puzzle)); err == nil || err.Error() != want {
+			t.Errorf("validatePuzzle(%s) = %v; want %q", puzzle, err, want)
+		}
+	}
+}
+
+func TestFindConflicts(t *testing.T) {
+	board := testGrid(t, testPuzzle)
+	board[0][3] = 9 // repeats the given 9 in its box
+	board[2][0] = 2 // two entered 2s in one row
+	board[2][3] = 2
+	board[4][4] = 5 // fits
+
+	// Both sides of a repeat are flagged, givens included, so the player
+	// can see what the digit clashes with.
+	want := map[coordinate]bool{{0, 3}: true, {1, 4}: true, {2, 0}: true, {2, 3}: true}
+	if got := findConflicts(board); !maps.Equal(got, want) {
+		t.Errorf("findConflicts = %v; want %v", got, want)
+	}
+	if got := findConflicts(testGrid(t, testSolution)); len(got) > 0 {
+		t.Errorf("findConflicts found %v in the solution", got)
+	}
+}
@@ -7,10 +7,11 @@ import (
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	env "github.com/muesli/termenv"
+	"github.com/charmbracelet/log"
 )
 
 const sudokuLen = 9
@@ -56,31 +57,35 @@ const (
 	ViewingLeaderboard
 	AdminPasswordEntry
 	AdminLeaderboardEdit
+	Paused
+	Exporting
+	Lost
 )
 
 type GameModel struct {
-	board                    [sudokuLen][sudokuLen]int
-	solution                 [sudokuLen][sudokuLen]int
-	initialBoard             [sudokuLen][sudokuLen]int
-	KeyMap                   KeyMap
-	cursor                   coordinate
-	cellsLeft                int
-	errCoordinates           map[coordinate]bool
-	originalErrCoordinates   map[coordinate]bool
-	modifiedErrCoordinates   map[coordinate]bool
-	remainingErrCoordinates  map[coordinate]bool
-	startTime                time.Time
-	width, height            int
-	difficulty               Difficulty
-	Err                      error
-	originalBgColor          env.Color
-	output                   *env.Output
+	board                   [sudokuLen][sudokuLen]int
+	solution                [sudokuLen][sudokuLen]int
+	initialBoard            [sudokuLen][sudokuLen]int
+	KeyMap                  KeyMap
+	cursor                  coordinate
+	cellsLeft               int
+	errCoordinates          map[coordinate]bool
+	originalErrCoordinates  map[coordinate]bool
+	modifiedErrCoordinates  map[coordinate]bool
+	remainingErrCoordinates map[coordinate]bool
+	startTime               time.Time
+	width, height           int
+	difficulty              Difficulty
+	Err                     error
+	theme                   *Theme
+	// help.ShowAll is set while the full help is shown over the screen.
+	help                     help.Model
 	state                    GameState
 	menuOptions              []string
 	selectedOption           int
 	elapsedTimeOnWin         time.Duration
 	blinkOn                  bool
-	leaderboard              *Leaderboard
+	leaderboard              *LeaderboardService
 	playerName               string
 	nameEntered              bool
 	adminPassword            string
@@ -88,20 +93,50 @@ type GameModel struct {
 	adminMode                bool
 	selectedLeaderboardEntry int
 	adminModeBuffer          string
+	hint                     *hint
+	hintsUsed                int
+	notes                    [sudokuLen][sudokuLen]candidates
+	notesMode                bool
+	autoRemoveNotes          bool
+	assist                   AssistLevel
+	mistakes                 int
+	mistakeLimit             int
+	highlightPeers           bool
+	highlightMatches         bool
+	spectatable              bool
+	noMistakesOnly           bool
+	history                  []move
+	future                   []move
+	session                  *Session
+	elapsedBefore            time.Duration
+	clockRunning             bool
+	id                       int
+	nameErr                  string
+	daily                    string
+	// seed is -1 for puzzles that weren't generated from a seed.
+	seed int64
+	// unranked is why the game's score can't be posted, if it can't.
+	unranked string
+	// room is the latest state of the multiplayer room the game is played
+	// in, if any.
+	room *roomStatus
+	// resumeState is the state to return to when the player leaves the
+	// pause screen or the game menu.
+	resumeState GameState
+	// moves counts the edits made and redone. Unlike the history, undoing
+	// doesn't take them back.
+	moves int
 }
 
-type setBackgroundColorMsg struct {
-	color env.Color
+func NewGameModel(width, height int, difficulty Difficulty, session *Session) *GameModel {
+	seed := newSeed()
+	board, solution := generateSudoku(seed, difficulty)
+	m := newGameModel(width, height, difficulty, board, solution, session)
+	m.seed = seed
+	return m
 }
 
-func setBackgroundColor(c env.Color) tea.Cmd {
-	return func() tea.Msg {
-		return setBackgroundColorMsg{color: c}
-	}
-}
-
-func NewGameModel(width, height int, difficulty Difficulty) *GameModel {
-	board, solution := generateSudoku(difficulty)
+func newGameModel(width, height int, difficulty Difficulty, board, solution [sudokuLen][sudokuLen]int, session *Session) *GameModel {
 	cellsLeft := 0
 	var initialBoard [sudokuLen][sudokuLen]int
 	for i := 0; i < sudokuLen; i++ {
@@ -113,124 +148,196 @@ func NewGameModel(width, height int, difficulty Difficulty) *GameModel {
 		}
 	}
 
-	var adminPassword string
-	passwordFilePath, err := findAdminPasswordFile()
-	if err == nil {
-		content, err := os.ReadFile(passwordFilePath)
-		if err == nil {
-			adminPassword = strings.TrimSpace(string(content))
-		}
-	}
-
-	leaderboard, err := LoadLeaderboardFromFile("sudoku_leaderboard.json")
-	if err != nil {
-		leaderboard = NewLeaderboard()
-	}
-
 	return &GameModel{
 		board:                    board,
 		solution:                 solution,
 		initialBoard:             initialBoard,
-		KeyMap:                   Keys,
+		KeyMap:                   session.KeyMap(),
 		cellsLeft:                cellsLeft,
 		errCoordinates:           make(map[coordinate]bool),
 		startTime:                time.Now(),
 		width:                    width,
 		height:                   height,
 		difficulty:               difficulty,
-		originalBgColor:          env.BackgroundColor(),
-		output:                   env.DefaultOutput(),
+		theme:                    session.Theme(),
+		help:                     newHelp(session.Theme(), width),
 		state:                    Playing,
 		menuOptions:              []string{"Resume Game", "New Game", "View Leaderboard", "Quit"},
 		selectedOption:           0,
-		leaderboard:              leaderboard,
+		leaderboard:              leaderboardService,
 		playerName:               "",
 		nameEntered:              false,
-		adminPassword:            strings.TrimSpace(string(adminPassword)),
+		adminPassword:            config.adminPassword(),
 		adminPasswordAttempt:     "",
 		adminMode:                false,
 		selectedLeaderboardEntry: 0,
 		adminModeBuffer:          "",
+		autoRemoveNotes:          true,
+		assist:                   session.Settings.Assist,
+		mistakeLimit:             session.Settings.MistakeLimit,
+		highlightPeers:           session.Settings.HighlightPeers,
+		highlightMatches:         session.Settings.HighlightMatches,
+		spectatable:              session.Settings.AllowSpectators,
+		session:                  session,
+		id:                       nextGameID(),
+		clockRunning:             true,
+		seed:                     -1,
 	}
 }
 
 func (m GameModel) Init() tea.Cmd {
-	return setBackgroundColor(env.RGBColor("#1e1e1e"))
+	return m.tick()
 }
 
+// Update handles msg and then shows spectators the game as it now is, or
+// tells them it ended if the player left it.
 func (m GameModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	model, cmd := m.update(msg)
+	if game, ok := model.(GameModel); ok {
+		game.publish()
+	} else {
+		liveGames.Remove(m.session)
+	}
+	return model, cmd
+}
+
+func (m GameModel) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
-	case setBackgroundColorMsg:
-		m.output.SetBackgroundColor(msg.color)
-		return m, nil
+	case tickMsg:
+		if msg.id != m.id {
+			return m, nil
+		}
+		// Saving every second keeps the saved time close to the clock, so
+		// disconnecting doesn't wind it back.
+		if m.clockRunning {
+			m.saveProgress()
+		}
+		return m, m.tick()
 
 	case tea.KeyMsg:
 		switch {
+		case key.Matches(msg, m.KeyMap.ForceQuit):
+			m.saveProgress()
+			return m, tea.Quit
+
+		case m.help.ShowAll:
+			m.help.ShowAll = false
+			return m, nil
+
+		case !m.typing() && m.state != Exporting && key.Matches(msg, m.KeyMap.Help):
+			m.help.ShowAll = true
+			return m, nil
+
 		case m.state == InMenu:
 			return m.updateMenu(msg)
 
+		case m.state == Exporting:
+			m.state = Playing
+			m.resumeClock()
+			m.refreshState()
+			return m, tea.EnterAltScreen
+
+		case m.state == Lost:
+			if key.Matches(msg, m.KeyMap.Select, m.KeyMap.Back) {
+				return m.backToMenu()
+			}
+
+		case m.state == Paused:
+			switch {
+			case key.Matches(msg, m.KeyMap.Pause):
+				m.resume()
+			case key.Matches(msg, m.KeyMap.Quit):
+				m.saveProgress()
+				return m, tea.Quit
+			}
+
 		case m.state == Won:
 			if !m.nameEntered {
-				switch msg.Type {
-				case tea.KeyEnter:
-					if m.playerName != "" {
+				choosingName := m.choosingName()
+				switch {
+				case key.Matches(msg, m.KeyMap.Confirm):
+					if m.session.KeyFingerprint == "" {
+						return m.backToMenu()
+					}
+					if m.unranked != "" {
 						m.nameEntered = true
-						m.SaveScore()
 						m.state = ViewingLeaderboard
 						return m, nil
 					}
-				case tea.KeyBackspace:
-					if len(m.playerName) > 0 {
-						m.playerName = m.playerName[:len(m.playerName)-1]
+					if choosingName {
+						if err := players.Register(m.session.KeyFingerprint, m.playerName); err != nil {
+							m.nameErr = err.Error()
+							return m, nil
+						}
+						m.session.Name = strings.TrimSpace(m.playerName)
+					}
+					m.playerName = m.session.Name
+					m.nameEntered = true
+					m.SaveScore()
+					m.state = ViewingLeaderboard
+					return m, nil
+				case msg.Type == tea.KeyBackspace:
+					if choosingName && len(m.playerName) > 0 {
+						runes := []rune(m.playerName)
+						m.playerName = string(runes[:len(runes)-1])
 					}
-				case tea.KeyRunes:
-					if len(m.playerName) < 20 {
+				case msg.Type == tea.KeyRunes, msg.Type == tea.KeySpace:
+					if choosingName && len([]rune(m.playerName)) < maxPlayerNameLen {
 						m.playerName += string(msg.Runes)
 					}
 				}
 			} else {
-				if msg.Type == tea.KeyEsc || msg.String() == "q" {
-					return NewMenuModel(m.width, m.height), nil
+				if key.Matches(msg, m.KeyMap.Back) {
+					return m.backToMenu()
 				}
 			}
 
 		case m.state == ViewingLeaderboard:
 			if !m.adminMode {
-				if msg.String() == "a" || (len(msg.Runes) > 0 && msg.Runes[0] == 'a') || msg.Type == tea.KeyRunes && string(msg.Runes) == "a" {
-					if m.adminPassword == "" {
-					} else {
+				if key.Matches(msg, m.KeyMap.AdminMode) {
+					if m.adminPassword != "" {
 						m.state = AdminPasswordEntry
 					}
 					return m, nil
 				}
-				if msg.Type == tea.KeyEsc || msg.String() == "q" {
+				if key.Matches(msg, m.KeyMap.Filter) {
+					m.noMistakesOnly = !m.noMistakesOnly
+					m.selectedLeaderboardEntry = 0
+					return m, nil
+				}
+				if key.Matches(msg, m.KeyMap.Back) {
 					if m.nameEntered {
-						return NewMenuModel(m.width, m.height), nil
+						return m.backToMenu()
 					} else {
 						m.state = Playing
+						m.resumeClock()
 					}
 					return m, nil
 				}
 			} else {
 
-				switch msg.String() {
-				case "up", "k":
+				switch {
+				case key.Matches(msg, m.KeyMap.Up):
 					m.selectedLeaderboardEntry = max(0, m.selectedLeaderboardEntry-1)
-				case "down", "j":
-					m.selectedLeaderboardEntry = min(len(m.leaderboard.Entries)-1, m.selectedLeaderboardEntry+1)
-				case "d":
-					m.leaderboard.DeleteEntry(m.selectedLeaderboardEntry)
-					m.leaderboard.SaveToFile("sudoku_leaderboard.json")
+				case key.Matches(msg, m.KeyMap.Down):
+					m.selectedLeaderboardEntry = min(len(m.topScores())-1, m.selectedLeaderboardEntry+1)
+				case key.Matches(msg, m.KeyMap.Delete):
+					topScores := m.topScores()
+					if m.selectedLeaderboardEntry < len(topScores) {
+						if err := m.leaderboard.DeleteEntry(topScores[m.selectedLeaderboardEntry]); err != nil {
+							log.Error("could not save leaderboard", "error", err)
+						}
+					}
 					m.selectedLeaderboardEntry = max(0, m.selectedLeaderboardEntry-1)
-				case "q", "esc":
+				case key.Matches(msg, m.KeyMap.Back):
 					m.adminMode = false
 					m.selectedLeaderboardEntry = 0
 				}
 			}
 
 		case m.state == AdminPasswordEntry:
-			switch msg.Type {
-			case tea.KeyEnter:
+			switch {
+			case key.Matches(msg, m.KeyMap.Confirm):
 				if strings.TrimSpace(m.adminPasswordAttempt) == strings.TrimSpace(m.adminPassword) {
 					m.adminMode = true
 					m.state = ViewingLeaderboard
@@ -241,20 +348,32 @@ func (m GameModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					fmt.Println("Incorrect password. Please try again.")
 				}
 				return m, nil
-			case tea.KeyBackspace:
+			case key.Matches(msg, m.KeyMap.Cancel):
+				m.state = ViewingLeaderboard
+				m.adminPasswordAttempt = ""
+				return m, nil
+			case msg.Type == tea.KeyBackspace:
 				if len(m.adminPasswordAttempt) > 0 {
 					m.adminPasswordAttempt = m.adminPasswordAttempt[:len(m.adminPasswordAttempt)-1]
 				}
-			case tea.KeyRunes:
+			case msg.Type == tea.KeyRunes:
 				m.adminPasswordAttempt += string(msg.Runes)
-			case tea.KeyEsc:
-				m.state = ViewingLeaderboard
-				m.adminPasswordAttempt = ""
-				return m, nil
 			}
 
 		case key.Matches(msg, m.KeyMap.Menu):
-			m.state = InMenu
+			m.leaveBoard(InMenu)
+			m.saveProgress()
+
+		case key.Matches(msg, m.KeyMap.Export):
+			m.state = Exporting
+			m.pauseClock()
+			// The alt screen cuts lines off at the terminal's width, so the
+			// export is printed to the normal screen instead.
+			return m, tea.Sequence(tea.ExitAltScreen, tea.Println(m.exportText()))
+
+		case key.Matches(msg, m.KeyMap.Pause):
+			m.leaveBoard(Paused)
+			m.saveProgress()
 
 		case key.Matches(msg, m.KeyMap.Down):
 			m.cursorDown()
@@ -269,17 +388,21 @@ func (m GameModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.cursorRight()
 
 		case key.Matches(msg, m.KeyMap.Number):
-			if m.state == Playing || m.state == NeedsCorrection {
-				m.set(m.cursor.row, m.cursor.col, int(msg.String()[0]-'0'))
+			if digit, ok := m.KeyMap.digit(msg); ok {
+				m.enterDigit(digit)
 			}
 
+		case key.Matches(msg, m.KeyMap.Notes):
+			m.notesMode = !m.notesMode
+
+		case key.Matches(msg, m.KeyMap.AutoRemoveNotes):
+			m.autoRemoveNotes = !m.autoRemoveNotes
+
 		case key.Matches(msg, m.KeyMap.Clear):
-			if m.initialBoard[m.cursor.row][m.cursor.col] == 0 {
-				m.clear(m.cursor.row, m.cursor.col)
-			}
+			m.clearCursor()
 			if m.cellsLeft == 0 {
 				checkMsg := m.check()()
-				return m.Update(checkMsg)
+				return m.update(checkMsg)
 			}
 			if key.Matches(msg, m.KeyMap.ViewLeaderboard) {
 				m.state = ViewingLeaderboard
@@ -287,28 +410,59 @@ func (m GameModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			}
 		case key.Matches(msg, m.KeyMap.ClearAll):
-			m.clearAllCells()
+			m.record(m.clearAllCells)
+
+		case key.Matches(msg, m.KeyMap.Undo):
+			m.undo()
+
+		case key.Matches(msg, m.KeyMap.Redo):
+			m.redo()
+
+		case key.Matches(msg, m.KeyMap.Hint):
+			// Asking again for the hint already shown doesn't count.
+			h := m.nextHint()
+			if len(h.cells) > 0 && (m.hint == nil || !h.equal(*m.hint)) {
+				m.hintsUsed++
+			}
+			m.hint = &h
 
 		case key.Matches(msg, m.KeyMap.Quit):
-			return m, tea.Sequence(
-				setBackgroundColor(m.originalBgColor),
-				tea.Quit,
-			)
+			m.saveProgress()
+			return m, tea.Quit
 
 		case key.Matches(msg, m.KeyMap.ViewLeaderboard):
 			if m.state == Playing {
 				m.state = ViewingLeaderboard
+				m.pauseClock()
 				return m, nil
 			}
 		}
 
+	case tea.MouseMsg:
+		if isLeftClick(msg) && (m.state == Playing || m.state == NeedsCorrection) {
+			return m.click(msg.X, msg.Y)
+		}
+
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
+		m.help.Width = msg.Width
+
+	case roomChangedMsg:
+		m.updateRoom(msg.status)
+
+	case leaderboardChangedMsg:
+		// Another session changed the scores; keep the admin selection on a
+		// row that still exists.
+		topScores := m.topScores()
+		m.selectedLeaderboardEntry = max(0, min(len(topScores)-1, m.selectedLeaderboardEntry))
 
 	case GameWon:
 		m.state = Won
-		m.elapsedTimeOnWin = time.Since(m.startTime)
+		m.elapsedTimeOnWin = m.elapsed()
+		m.pauseClock()
+		m.discardSave()
+		m.finishRace()
 
 	case GameNeedsCorrection:
 		m.state = NeedsCorrection
@@ -328,34 +482,50 @@ func (m GameModel) updateMenu(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.selectedOption = (m.selectedOption - 1 + len(m.menuOptions)) % len(m.menuOptions)
 	case key.Matches(msg, m.KeyMap.Down):
 		m.selectedOption = (m.selectedOption + 1) % len(m.menuOptions)
-	case key.Matches(msg, m.KeyMap.Number), key.Matches(msg, key.NewBinding(key.WithKeys("enter"))):
+	case key.Matches(msg, m.KeyMap.Select):
 		switch m.selectedOption {
 		case 0:
-			m.state = Playing
+			m.resume()
 			return m, nil
 		case 1:
-			return NewMenuModel(m.width, m.height), nil
+			return m.backToMenu()
 		case 2:
 			m.state = ViewingLeaderboard
 			return m, nil
 		case 3:
+			m.saveProgress()
 			return m, tea.Quit
 		}
 	}
 	return m, nil
 }
 
+// backToMenu ends the game, leaving its room if it has one.
+func (m GameModel) backToMenu() (tea.Model, tea.Cmd) {
+	m.leaveRoom()
+	return NewMenuModel(m.width, m.height, m.session), nil
+}
+
 func (m GameModel) View() string {
+	if m.state == Exporting {
+		return m.renderExport()
+	}
 	var content string
-	switch m.state {
-	case InMenu:
+	switch {
+	case m.help.ShowAll:
+		content = m.renderHelpOverlay()
+	case m.state == InMenu:
 		content = m.renderMenu()
-	case Won:
+	case m.state == Won:
 		content = m.renderWinScreen()
-	case ViewingLeaderboard:
+	case m.state == ViewingLeaderboard:
 		content = m.renderLeaderboard()
-	case AdminPasswordEntry:
+	case m.state == AdminPasswordEntry:
 		content = m.renderAdminPasswordEntry()
+	case m.state == Paused:
+		content = m.renderPaused()
+	case m.state == Lost:
+		content = m.renderLost()
 	default:
 		content = m.renderGame()
 	}
@@ -365,57 +535,62 @@ func (m GameModel) View() string {
 		content)
 }
 
-var selectedMarkerStyle = lipgloss.NewStyle().
-	Foreground(lipgloss.Color("205"))
-
 func (m GameModel) renderMenu() string {
 	var s strings.Builder
 	s.WriteString("Menu\n\n")
 	for i, option := range m.menuOptions {
 		if i == m.selectedOption {
 			// Apply the style to the '>' character
-			s.WriteString(selectedMarkerStyle.Render("> "))
+			s.WriteString(m.theme.markerStyle().Render("> "))
 		} else {
 			s.WriteString("  ")
 		}
 		s.WriteString(option + "\n")
 	}
+	s.WriteString("\n" + m.help.View(m.helpKeys()))
 	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, s.String())
 }
 
 func (m GameModel) renderWinScreen() string {
-	boxStyle := lipgloss.NewStyle().
-		Border(lipgloss.RoundedBorder()).
-		Padding(2, 6).
-		BorderBackground(lipgloss.Color("11")).
-		BorderForeground(lipgloss.Color("11")).
-		Background(lipgloss.Color("11"))
-
-	textStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("0")).
-		Bold(true)
-
-	titleStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("196")).
-		Bold(true).
+	boxStyle := m.theme.boxStyle().Padding(2, 6)
+	textStyle := m.theme.boxTextStyle().Bold(true)
+	titleStyle := textStyle.
+		Foreground(m.theme.BoxTitle).
 		Align(lipgloss.Center)
 
-	namePrompt := "Enter your name: "
-	if m.nameEntered {
-		namePrompt = fmt.Sprintf("Name: %s", m.playerName)
+	confirm := m.KeyMap.Confirm.Help().Key
+	var namePrompt, instructionText string
+	switch {
+	case m.session.KeyFingerprint == "":
+		namePrompt = "Log in with an SSH key to post scores"
+		instructionText = fmt.Sprintf("Press %s to return to the menu", confirm)
+	case m.unranked != "":
+		namePrompt = "This run is unranked because " + m.unranked
+		instructionText = fmt.Sprintf("Press %s to view the leaderboard", confirm)
+	case m.session.Name == "":
+		namePrompt = "Pick a display name for your SSH key: " + m.playerName
+		instructionText = fmt.Sprintf("Type your name and press %s", confirm)
+		if m.nameErr != "" {
+			instructionText = m.nameErr
+		}
+	default:
+		namePrompt = "Playing as " + m.session.Name
+		instructionText = fmt.Sprintf("Press %s to save score", confirm)
 	}
 
-	var instructionText string
-	if m.nameEntered {
-		instructionText = "Press Enter to save score"
-	} else {
-		instructionText = "Type your name and press Enter"
+	details := []string{
+		textStyle.Render(fmt.Sprintf("Time: %02d:%02d", int(m.elapsedTimeOnWin.Minutes()), int(m.elapsedTimeOnWin.Seconds())%60)),
+		textStyle.Render(fmt.Sprintf("Hints used: %d", m.hintsUsed)),
+		textStyle.Render(m.seedText()),
+	}
+	if m.inRoom(coopMode) {
+		details = append(details, textStyle.Render(m.coopCredits()))
 	}
 
 	winMessage := fmt.Sprintf("%s\n\n%s\n\n%s\n\n%s",
-		titleStyle.Bold(true).Render("You Win!!!"),
-		textStyle.Render(fmt.Sprintf("Time: %02d:%02d", int(m.elapsedTimeOnWin.Minutes()), int(m.elapsedTimeOnWin.Seconds())%60)),
-		textStyle.Render(namePrompt+m.playerName),
+		titleStyle.Render("You Win!!!"),
+		strings.Join(details, "\n"),
+		textStyle.Render(namePrompt),
 		textStyle.Render(instructionText))
 
 	boxedWinMessage := boxStyle.Render(winMessage)
@@ -424,20 +599,32 @@ func (m GameModel) renderWinScreen() string {
 }
 
 func (m GameModel) renderLeaderboard() string {
-	topScores := m.leaderboard.GetTopScores(m.difficulty, 10)
+	topScores := m.topScores()
 
 	var s strings.Builder
-	s.WriteString(fmt.Sprintf("Leaderboard - %s\n\n", m.difficulty))
-	s.WriteString(fmt.Sprintf("%-4s %-20s %-10s %-10s\n", "Rank", "Name", "Time", "Date"))
-	s.WriteString(fmt.Sprintf("%-4s %-20s %-10s %-10s\n", "----", "----", "----", "----"))
+	title := fmt.Sprintf("Leaderboard - %s", m.difficulty)
+	if m.daily != "" {
+		title = fmt.Sprintf("Daily Leaderboard - %s - %s", m.daily, m.difficulty)
+	} else if m.inRoom(raceMode) {
+		title = fmt.Sprintf("Race Leaderboard - %s", m.difficulty)
+	}
+	if m.noMistakesOnly {
+		title += " - no-mistake runs"
+	}
+	s.WriteString(title + "\n\n")
+	s.WriteString(fmt.Sprintf("%-4s %-20s %-10s %-5s %-9s %-8s %-10s\n", "Rank", "Name", "Time", "Hints", "Assist", "Mistakes", "Date"))
+	s.WriteString(fmt.Sprintf("%-4s %-20s %-10s %-5s %-9s %-8s %-10s\n", "----", "----", "----", "-----", "------", "--------", "----"))
 
 	for i, entry := range topScores {
 		formattedTime := formatDuration(entry.Time)
 		formattedDate := entry.Date.Format("2006-01-02")
-		line := fmt.Sprintf("%-4d %-20s %-10s %-10s",
+		line := fmt.Sprintf("%-4d %-20s %-10s %-5d %-9s %-8d %-10s",
 			i+1,
 			truncateString(entry.Name, 20),
 			formattedTime,
+			entry.Hints,
+			entry.Assist,
+			entry.Mistakes,
 			formattedDate,
 		)
 		if m.adminMode && i == m.selectedLeaderboardEntry {
@@ -449,10 +636,9 @@ func (m GameModel) renderLeaderboard() string {
 	}
 
 	if m.adminMode {
-		s.WriteString("\nAdmin Mode: Use up/down to select, 'd' to delete, 'q' to exit admin mode")
-	} else {
-		s.WriteString("\nPress 'a' for admin mode, 'q' or 'esc' to return to menu")
+		s.WriteString("\nAdmin Mode\n")
 	}
+	s.WriteString("\n" + m.help.View(m.helpKeys()))
 
 	return s.String()
 }
@@ -461,7 +647,7 @@ func (m GameModel) renderAdminPasswordEntry() string {
 	prompt := "Enter admin password: "
 	maskedPassword := strings.Repeat("*", len(m.adminPasswordAttempt))
 
-	message := fmt.Sprintf("%s%s\n\nPress Enter to submit or Esc to cancel", prompt, maskedPassword)
+	message := fmt.Sprintf("%s%s\n\n%s", prompt, maskedPassword, m.help.View(m.helpKeys()))
 
 	return lipgloss.Place(m.width, m.height,
 		lipgloss.Center, lipgloss.Center,
@@ -469,78 +655,132 @@ func (m GameModel) renderAdminPasswordEntry() string {
 }
 
 func (m GameModel) renderGame() string {
+	view, _ := m.layoutGame()
+	return view
+}
+
+// layoutGame renders the game screen and reports where on it the board and
+// number pad were drawn.
+func (m GameModel) layoutGame() (string, gameLayout) {
 	boardView := m.renderBoard()
+	padView := m.renderNumberPad()
 	infoView := m.renderInfo()
 
 	var statusView string
 	switch m.state {
 	case NeedsCorrection:
-		statusView = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF0000")).Render("The solution is incorrect. Please check the highlighted cells and try again.")
+		statusView = m.theme.failureStyle().Render("The solution is incorrect. Please check the highlighted cells and try again.")
+	}
+	if m.hint != nil {
+		statusView = lipgloss.JoinVertical(lipgloss.Center, statusView, m.theme.hintTextStyle().Render(m.hint.explanation))
 	}
 
-	mainView := lipgloss.JoinVertical(lipgloss.Center, boardView, infoView, statusView)
-	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, mainView)
+	boardRow := lipgloss.JoinHorizontal(lipgloss.Top, boardView, lipgloss.NewStyle().MarginLeft(padMargin).Render(padView))
+	var panelView string
+	switch {
+	case m.inRoom(raceMode):
+		panelView = m.renderRacePanel()
+	case m.inRoom(coopMode):
+		panelView = m.renderCoopPanel()
+	}
+	if panelView != "" {
+		boardRow = lipgloss.JoinHorizontal(lipgloss.Top, boardRow, lipgloss.NewStyle().MarginLeft(padMargin).Render(panelView))
+	}
+	mainView := lipgloss.JoinVertical(lipgloss.Center, boardRow, infoView, statusView)
+
+	mainWidth := lipgloss.Width(mainView)
+	top := placeOffset(m.height, lipgloss.Height(mainView))
+	left := placeOffset(m.width, mainWidth) + joinOffset(mainWidth, lipgloss.Width(boardRow))
+	layout := gameLayout{
+		board: coordinate{top, left},
+		pad:   coordinate{top, left + lipgloss.Width(boardView) + padMargin},
+		tall:  m.showsNotes(),
+	}
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, mainView), layout
 }
 
 func (m GameModel) renderBoard() string {
 	var boardView strings.Builder
+	tall := m.showsNotes()
+	assistErrs := m.assistErrors()
+	partners := m.partnerCursors()
 
 	for i := 0; i < sudokuLen; i++ {
-		var row strings.Builder
+		var row []string
 		for j := 0; j < sudokuLen; j++ {
 			value := m.board[i][j]
 			cellValue := " "
 			if value != 0 {
 				cellValue = fmt.Sprintf("%d", value)
 			}
+			isNotes := false
+			if tall {
+				if value == 0 && m.notes[i][j] != 0 {
+					cellValue = formatNotes(m.notes[i][j])
+					isNotes = true
+				} else {
+					cellValue = "   \n " + cellValue + " \n   "
+				}
+			}
 
 			isInitial := m.initialBoard[i][j] != 0
 			isCursor := m.cursor.row == i && m.cursor.col == j
 			coord := coordinate{i, j}
 
-			isError := m.remainingErrCoordinates[coord]
-
-			cellStr := formatCell(isError, isCursor, !isInitial, i, j, cellValue)
-			row.WriteString(cellStr)
+			cellStr := m.theme.formatCell(cellFlags{
+				isError:    m.remainingErrCoordinates[coord] || assistErrs[coord],
+				isCursor:   isCursor,
+				partner:    partners[coord],
+				isHinted:   m.hint.highlights(coord),
+				isPeer:     m.highlightPeers && m.seesCursor(coord),
+				isMatch:    m.highlightMatches && m.matchesCursor(coord),
+				isNotes:    isNotes,
+				modifiable: !isInitial,
+			}, i, j, cellValue)
+			row = append(row, cellStr)
 		}
-		rowStr := formatRow(i, row.String())
+		rowStr := formatRow(i, lipgloss.JoinHorizontal(lipgloss.Top, row...))
 		boardView.WriteString(rowStr + "\n")
 	}
 	return boardView.String()
 }
 
 func (m GameModel) renderInfo() string {
-	// Style definitions
-	headerStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("15")).
-		Bold(true).
-		Padding(0, 1)
-
-	infoStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("15")).
-		Padding(0, 1)
-
-	controlsStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("33")).
-		Italic(true).
-		Padding(0, 1)
+	headerStyle := m.theme.textStyle().Bold(true).Padding(0, 1)
+	infoStyle := m.theme.textStyle().Padding(0, 1)
 
 	var elapsedTime time.Duration
 	if m.state == Won {
 		elapsedTime = m.elapsedTimeOnWin
 	} else {
-		elapsedTime = time.Since(m.startTime).Round(time.Second)
+		elapsedTime = m.elapsed().Round(time.Second)
 	}
 
-	header := headerStyle.Render(fmt.Sprintf("Sudoku - %s", m.difficulty))
+	header := headerStyle.Render(fmt.Sprintf("Sudoku - %s - %s", m.difficulty, m.seedText()))
+
+	inputMode := "digits"
+	if m.notesMode {
+		inputMode = "notes"
+	}
+	autoRemove := "off"
+	if m.autoRemoveNotes {
+		autoRemove = "on"
+	}
 
 	gameInfo := infoStyle.Render(fmt.Sprintf("Cells left: %d\n"+
-		"Elapsed time: %02d:%02d",
+		"Elapsed time: %02d:%02d\n"+
+		"Moves made: %d\n"+
+		"Input: %s (auto-remove notes %s)\n"+
+		"Assist: %s\n"+
+		"Mistakes: %s",
 		m.cellsLeft,
-		int(elapsedTime.Minutes()), int(elapsedTime.Seconds())%60))
+		int(elapsedTime.Minutes()), int(elapsedTime.Seconds())%60,
+		m.moves,
+		inputMode, autoRemove,
+		m.assist,
+		m.mistakesText()))
 
-	controls := controlsStyle.Render("q/esc: quit • m: menu • b: leaderboard • ⌫ clear cell • C: clear all\n" +
-		"Use arrow keys to move, numbers to fill")
+	controls := lipgloss.NewStyle().Padding(0, 1).Render(m.help.View(m.helpKeys()))
 
 	info := lipgloss.JoinVertical(
 		lipgloss.Left,
@@ -555,24 +795,52 @@ func (m GameModel) renderInfo() string {
 		Render(info)
 }
 
+// enterDigit puts digit in the cursor's cell, or toggles it as a note in
+// notes mode.
+func (m *GameModel) enterDigit(digit int) {
+	if m.state != Playing && m.state != NeedsCorrection {
+		return
+	}
+	if m.notesMode {
+		m.record(func() { m.toggleNote(m.cursor.row, m.cursor.col, digit) })
+	} else {
+		previous := m.board[m.cursor.row][m.cursor.col]
+		m.record(func() { m.set(m.cursor.row, m.cursor.col, digit) })
+		if m.board[m.cursor.row][m.cursor.col] != previous {
+			m.countMistake(m.cursor.row, m.cursor.col)
+		}
+	}
+}
+
+func (m *GameModel) clearCursor() {
+	if m.initialBoard[m.cursor.row][m.cursor.col] == 0 {
+		m.record(func() { m.clear(m.cursor.row, m.cursor.col) })
+	}
+}
+
 func (m *GameModel) cursorDown() {
 	m.cursor.row = (m.cursor.row + 1) % sudokuLen
+	m.shareCursor()
 }
 
 func (m *GameModel) cursorUp() {
 	m.cursor.row = (m.cursor.row - 1 + sudokuLen) % sudokuLen
+	m.shareCursor()
 }
 
 func (m *GameModel) cursorLeft() {
 	m.cursor.col = (m.cursor.col - 1 + sudokuLen) % sudokuLen
+	m.shareCursor()
 }
 
 func (m *GameModel) cursorRight() {
 	m.cursor.col = (m.cursor.col + 1) % sudokuLen
+	m.shareCursor()
 }
 
 func (m *GameModel) clear(row, col int) {
 	if m.board[row][col] != 0 && m.initialBoard[row][col] == 0 {
+		m.hint = nil
 		m.board[row][col] = 0
 		m.cellsLeft++
 
@@ -615,8 +883,15 @@ func (m *GameModel) recheckBoard() {
 
 func (m *GameModel) set(row, col, value int) {
 	if m.initialBoard[row][col] == 0 {
+		m.hint = nil
 		previousValue := m.board[row][col]
 		m.board[row][col] = value
+		if value != 0 {
+			m.notes[row][col] = 0
+			if m.autoRemoveNotes {
+				m.removeNoteFromPeers(row, col, value)
+			}
+		}
 
 		if previousValue == 0 && value != 0 {
 			m.cellsLeft--
@@ -681,11 +956,34 @@ func (m *GameModel) updateErrCoordinates() {
 	m.remainingErrCoordinates = m.copyCoordinates(m.errCoordinates)
 }
 
+// assistErrors returns the cells to flag while playing, according to the
+// assist level the game was started with.
+func (m GameModel) assistErrors() map[coordinate]bool {
+	switch m.assist {
+	case AssistConflicts:
+		return findConflicts(m.board)
+	case AssistStrict:
+		errs := make(map[coordinate]bool)
+		for i := 0; i < sudokuLen; i++ {
+			for j := 0; j < sudokuLen; j++ {
+				if m.board[i][j] != 0 && m.board[i][j] != m.solution[i][j] {
+					errs[coordinate{i, j}] = true
+				}
+			}
+		}
+		return errs
+	}
+	return nil
+}
+
 func (m *GameModel) updateGameState() {
 	if m.cellsLeft == 0 {
 		if len(m.errCoordinates) == 0 {
 			m.state = Won
-			m.elapsedTimeOnWin = time.Since(m.startTime)
+			m.elapsedTimeOnWin = m.elapsed()
+			m.pauseClock()
+			m.discardSave()
+			m.finishRace()
 		} else {
 			m.state = NeedsCorrection
 		}
@@ -721,15 +1019,42 @@ func (m *GameModel) check() tea.Cmd {
 
 func (m *GameModel) SaveScore() {
 	if m.playerName != "" {
-		m.leaderboard.AddEntry(m.playerName, m.elapsedTimeOnWin, m.difficulty)
-		err := m.leaderboard.SaveToFile("sudoku_leaderboard.json")
+		err := m.leaderboard.AddEntry(LeaderboardEntry{
+			Name:        m.playerName,
+			Fingerprint: m.session.KeyFingerprint,
+			Time:        m.elapsedTimeOnWin,
+			Difficulty:  m.difficulty,
+			Hints:       m.hintsUsed,
+			Assist:      m.assist,
+			Mistakes:    m.mistakes,
+			Daily:       m.daily,
+		})
 		if err != nil {
-			fmt.Println("Error saving leaderboard:", err)
+			log.Error("could not save leaderboard", "error", err)
 		}
 	}
 	//m.nameEntered = false
 }
 
+// seedText names the puzzle. Daily puzzles don't show their seeds.
+func (m GameModel) seedText() string {
+	if m.daily != "" {
+		return "Daily puzzle " + m.daily
+	}
+	if m.seed < 0 {
+		return "Custom puzzle"
+	}
+	return "Seed " + seedCode(m.seed, m.difficulty)
+}
+
+// topScores returns the leaderboard rows shown for this game: its
+// difficulty, and for daily games only that day's puzzle.
+func (m GameModel) topScores() []LeaderboardEntry {
+	return m.leaderboard.GetTopScoresWhere(func(e LeaderboardEntry) bool {
+		return e.Difficulty == m.difficulty && e.Daily == m.daily && (!m.noMistakesOnly || e.Mistakes == 0)
+	}, leaderboardSize)
+}
+
 func formatDuration(d time.Duration) string {
 	m := d / time.Minute
 	d -= m * time.Minute
@@ -781,9 +1106,11 @@ func (m GameModel) getPasswordFileStatus() string {
 }
 
 func (m *GameModel) clearAllCells() {
+	m.hint = nil
 	for i := 0; i < sudokuLen; i++ {
 		for j := 0; j < sudokuLen; j++ {
 			if m.initialBoard[i][j] == 0 {
+				m.notes[i][j] = 0
 				if m.board[i][j] != 0 {
 					m.board[i][j] = 0
 					m.cellsLeft++
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"maps"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestAssistErrors(t *testing.T) {
+	m := newTestGame(t)
+	m.board[0][2] = 2 // wrong, though nothing it can see shows that
+	m.board[0][3] = 9 // repeats the given 9 in its box
+	m.board[4][4] = 5 // right
+
+	for assist, want := range map[AssistLevel]map[coordinate]bool{
+		AssistOff:       {},
+		AssistConflicts: {{0, 3}: true, {1, 4}: true},
+		// Strict checks against the solution, so it finds the hidden
+		// mistake but never flags a given.
+		AssistStrict: {{0, 2}: true, {0, 3}: true},
+	} {
+		m.assist = assist
+		if got := m.assistErrors(); !maps.Equal(got, want) {
+			t.Errorf("%v: assistErrors = %v; want %v", assist, got, want)
+		}
+	}
+}
+
+// The export is printed, not drawn, so even on a narrow terminal every form
+// of it comes out whole and can be copied as it is.
+func TestExportIsPrintedWhole(t *testing.T) {
+	m := newTestGame(t)
+	m.KeyMap = Keys
+	m.width, m.height = 40, 20
+	m.board[0][2] = 4
+
+	model, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(Keys.Export.Keys()[0])})
+	got := model.(GameModel)
+	if got.state != Exporting || cmd == nil {
+		t.Fatalf("state %v, command %v; want the export to be printed", got.state, cmd)
+	}
+	if view := got.View(); strings.Contains(view, formatLine(m.initialBoard)) {
+		t.Error("the export is drawn on the screen, which cuts it at the terminal's width")
+	}
+
+	export := got.exportText()
+	json := export[strings.Index(export, "JSON:\n")+len("JSON:\n"):]
+	if g, p, err := parsePuzzle(json); err != nil || g != m.initialBoard || p != m.board {
+		t.Errorf("the printed JSON came back as %s, %s, %v", formatLine(g), formatLine(p), err)
+	}
+	if !strings.Contains(export, "\n"+formatLine(m.board)+"\n") {
+		t.Error("the progress isn't printed on one line")
+	}
+}
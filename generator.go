@@ -1,32 +1,55 @@
 package main
 
-import (
-	"math/rand"
-	"time"
-)
+import "math/rand"
 
-func init() {
-	rand.Seed(time.Now().UnixNano())
+// generateSudoku generates the puzzle for a seed. The same seed and
+// difficulty always produce the same puzzle.
+func generateSudoku(seed int64, difficulty Difficulty) ([9][9]int, [9][9]int) {
+	return generateSudokuWith(rand.New(rand.NewSource(seed)), difficulty)
 }
 
-func generateSudoku(difficulty Difficulty) ([9][9]int, [9][9]int) {
-	var board, solution [9][9]int
-	fillBoard(&solution)
-	board = solution
-	removeCells(&board, difficulty)
-	return board, solution
+// maxGenerateAttempts bounds the grids tried for one puzzle. Around a third
+// of grids grade at the difficulty asked for, so running out of attempts is
+// all but impossible, but a generator that can't loop forever needs no
+// timeout.
+const maxGenerateAttempts = 100
+
+// generateSudokuWith generates a puzzle using only r for randomness, so the
+// same source state always yields the same puzzle. A given grid may not
+// yield a puzzle that needs the difficulty's techniques, so it tries new
+// grids until one grades at the difficulty asked for. If none does within
+// maxGenerateAttempts, it settles for the hardest-graded puzzle it made,
+// which is never harder than asked for.
+func generateSudokuWith(r *rand.Rand, difficulty Difficulty) ([9][9]int, [9][9]int) {
+	var bestBoard, bestSolution [9][9]int
+	bestGrade := Technique(-1)
+	for attempt := 0; attempt < maxGenerateAttempts; attempt++ {
+		var solution [9][9]int
+		fillBoard(&solution, r)
+		board := solution
+		removeCells(&board, difficulty, r)
+
+		grade, _ := gradePuzzle(board)
+		if grade.Difficulty() == difficulty {
+			return board, solution
+		}
+		if grade > bestGrade {
+			bestBoard, bestSolution, bestGrade = board, solution, grade
+		}
+	}
+	return bestBoard, bestSolution
 }
 
-func fillBoard(board *[9][9]int) bool {
+func fillBoard(board *[9][9]int, r *rand.Rand) bool {
 	for i := 0; i < 9; i++ {
 		for j := 0; j < 9; j++ {
 			if board[i][j] == 0 {
-				nums := rand.Perm(9)
+				nums := r.Perm(9)
 				for _, n := range nums {
 					num := n + 1
 					if isValid(*board, i, j, num) {
 						board[i][j] = num
-						if fillBoard(board) {
+						if fillBoard(board, r) {
 							return true
 						}
 						board[i][j] = 0
@@ -39,35 +62,37 @@ func fillBoard(board *[9][9]int) bool {
 	return true
 }
 
-func removeCells(board *[9][9]int, difficulty Difficulty) {
+// removeCells empties cells in random order, keeping each removal only if
+// the puzzle still has a unique solution that can be reached logically
+// without techniques harder than the difficulty allows.
+func removeCells(board *[9][9]int, difficulty Difficulty, r *rand.Rand) {
 	cellsToRemove := 0
 	switch difficulty {
 	case Easy:
-		cellsToRemove = 30
-	case Medium:
 		cellsToRemove = 40
+	case Medium:
+		cellsToRemove = 58
 	case Hard:
-		cellsToRemove = 50
+		cellsToRemove = 64
 	}
 
-	attempts := cellsToRemove + 20
-	for cellsToRemove > 0 && attempts > 0 {
-		row := rand.Intn(9)
-		col := rand.Intn(9)
-		if board[row][col] != 0 {
-			backup := board[row][col]
-			board[row][col] = 0
-
-			tempBoard := *board
-			solutions := countSolutions(tempBoard)
+	for _, pos := range r.Perm(81) {
+		if cellsToRemove == 0 {
+			return
+		}
+		row, col := pos/9, pos%9
+		backup := board[row][col]
+		board[row][col] = 0
 
-			if solutions != 1 {
-				board[row][col] = backup
-				attempts--
-			} else {
-				cellsToRemove--
-			}
+		if countSolutions(*board) != 1 {
+			board[row][col] = backup
+			continue
 		}
+		if grade, solved := gradePuzzle(*board); !solved || grade.Difficulty() > difficulty {
+			board[row][col] = backup
+			continue
+		}
+		cellsToRemove--
 	}
 }
 
@@ -92,29 +117,54 @@ func isValid(board [9][9]int, row, col, num int) bool {
 
 func countSolutions(board [9][9]int) int {
 	count := 0
-	solve(&board, &count)
+	solve(&board, &count, nil)
 	return count
 }
 
-func solve(board *[9][9]int, count *int) {
+// solveSudoku returns the first solution found for board, and whether it is
+// the only one.
+func solveSudoku(board [9][9]int) ([9][9]int, bool) {
+	count := 0
+	var solution [9][9]int
+	solve(&board, &count, &solution)
+	return solution, count == 1
+}
+
+// solve counts solutions by backtracking, always branching on the empty cell
+// with the fewest options so that proving uniqueness stays fast even for
+// sparse puzzles.
+func solve(board *[9][9]int, count *int, solution *[9][9]int) {
 	if *count > 1 {
 		return
 	}
-	for i := 0; i < 9; i++ {
+	bestRow, bestCol, bestOptions := -1, -1, 10
+	for i := 0; i < 9 && bestOptions > 1; i++ {
 		for j := 0; j < 9; j++ {
 			if board[i][j] == 0 {
-				nums := rand.Perm(9)
-				for _, n := range nums {
-					num := n + 1
+				options := 0
+				for num := 1; num <= 9; num++ {
 					if isValid(*board, i, j, num) {
-						board[i][j] = num
-						solve(board, count)
-						board[i][j] = 0
+						options++
 					}
 				}
-				return
+				if options < bestOptions {
+					bestRow, bestCol, bestOptions = i, j, options
+				}
 			}
 		}
 	}
-	*count++
+	if bestRow == -1 {
+		*count++
+		if solution != nil && *count == 1 {
+			*solution = *board
+		}
+		return
+	}
+	for num := 1; num <= 9; num++ {
+		if isValid(*board, bestRow, bestCol, num) {
+			board[bestRow][bestCol] = num
+			solve(board, count, solution)
+			board[bestRow][bestCol] = 0
+		}
+	}
 }
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// generateTimeout is far longer than generating ever takes, so that a
+// generator stuck retrying fails the test rather than hanging it.
+const generateTimeout = 10 * time.Second
+
+func TestGenerateSudokuTerminates(t *testing.T) {
+	for _, difficulty := range []Difficulty{Easy, Medium, Hard} {
+		t.Run(difficulty.String(), func(t *testing.T) {
+			for seed := int64(0); seed < 5; seed++ {
+				done := make(chan [2][sudokuLen][sudokuLen]int, 1)
+				go func() {
+					puzzle, solution := generateSudoku(seed, difficulty)
+					done <- [2][sudokuLen][sudokuLen]int{puzzle, solution}
+				}()
+				var generated [2][sudokuLen][sudokuLen]int
+				select {
+				case generated = <-done:
+				case <-time.After(generateTimeout):
+					t.Fatalf("generating a puzzle took over %v", generateTimeout)
+				}
+
+				puzzle, solution := generated[0], generated[1]
+				if countSolutions(puzzle) != 1 {
+					t.Fatal("the puzzle doesn't have exactly one solution")
+				}
+				grade, solved := gradePuzzle(puzzle)
+				if !solved || grade.Difficulty() > difficulty {
+					t.Fatalf("the puzzle graded %v (solved %v), harder than %v", grade, solved, difficulty)
+				}
+				for i := 0; i < sudokuLen; i++ {
+					for j := 0; j < sudokuLen; j++ {
+						if puzzle[i][j] != 0 && puzzle[i][j] != solution[i][j] {
+							t.Fatalf("the given at %v isn't the solution's digit", coordinate{i, j})
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+// Seed codes are only worth sharing if everyone gets the same puzzle.
+func TestGenerateSudokuIsDeterministic(t *testing.T) {
+	const seed = 1<<seedBits - 1
+	puzzle, solution := generateSudoku(seed, Medium)
+	again, againSolution := generateSudoku(seed, Medium)
+	if puzzle != again || solution != againSolution {
+		t.Fatal("the same seed generated two different puzzles")
+	}
+	if other, _ := generateSudoku(seed-1, Medium); other == puzzle {
+		t.Fatal("neighbouring seeds generated the same puzzle")
+	}
+}
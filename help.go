@@ -0,0 +1,106 @@
+package main
+
+import (
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// bindingHelp is the help of a screen with only a few keys, which all fit
+// in its short help.
+type bindingHelp []key.Binding
+
+func (b bindingHelp) ShortHelp() []key.Binding {
+	return b
+}
+
+func (b bindingHelp) FullHelp() [][]key.Binding {
+	return [][]key.Binding{b}
+}
+
+// relabel returns b with a description that fits the screen it's shown on.
+func relabel(b key.Binding, desc string) key.Binding {
+	b.SetHelp(b.Help().Key, desc)
+	return b
+}
+
+func newHelp(theme *Theme, width int) help.Model {
+	h := help.New()
+	h.Width = width
+	keyStyle := lipgloss.NewStyle().Foreground(theme.Controls).Bold(true)
+	descStyle := lipgloss.NewStyle().Foreground(theme.Controls).Italic(true)
+	sepStyle := lipgloss.NewStyle().Foreground(theme.DoneText)
+	h.Styles = help.Styles{
+		Ellipsis:       sepStyle,
+		ShortKey:       keyStyle,
+		ShortDesc:      descStyle,
+		ShortSeparator: sepStyle,
+		FullKey:        keyStyle,
+		FullDesc:       descStyle,
+		FullSeparator:  sepStyle,
+	}
+	return h
+}
+
+// helpKeys returns the keys that work in the game's current state.
+func (m GameModel) helpKeys() help.KeyMap {
+	k := m.KeyMap
+	switch {
+	case m.state == InMenu:
+		return bindingHelp{k.Up, k.Down, k.Select, k.Help}
+	case m.state == ViewingLeaderboard && m.adminMode:
+		return bindingHelp{k.Up, k.Down, k.Delete, relabel(k.Back, "leave admin mode"), k.Help}
+	case m.state == ViewingLeaderboard:
+		back := "back to menu"
+		if !m.nameEntered {
+			back = "back to game"
+		}
+		keys := bindingHelp{k.Filter, relabel(k.Back, back), k.Help}
+		if m.adminPassword != "" {
+			keys = append(bindingHelp{k.AdminMode}, keys...)
+		}
+		return keys
+	case m.state == AdminPasswordEntry:
+		return bindingHelp{relabel(k.Confirm, "submit"), k.Cancel}
+	case m.state == Paused:
+		return bindingHelp{relabel(k.Pause, "resume"), k.Quit, k.Help}
+	case m.state == Lost, m.state == Won && m.nameEntered:
+		return bindingHelp{relabel(k.Select, "back to menu"), relabel(k.Back, "back to menu"), k.Help}
+	case m.choosingName():
+		return bindingHelp{k.Confirm}
+	case m.state == Won:
+		return bindingHelp{k.Confirm, k.Help}
+	}
+	return k
+}
+
+// typing reports whether keys are being typed into a text field, so that
+// they shouldn't trigger actions.
+func (m GameModel) typing() bool {
+	return m.state == AdminPasswordEntry || m.choosingName()
+}
+
+// choosingName reports whether the win screen is asking for a display name,
+// which it only does when a ranked score is won with a key that has none.
+func (m GameModel) choosingName() bool {
+	return m.state == Won && !m.nameEntered && m.unranked == "" &&
+		m.session.KeyFingerprint != "" && m.session.Name == ""
+}
+
+// renderHelpOverlay shows every key of the current state over the screen.
+func (m GameModel) renderHelpOverlay() string {
+	full := m.help
+	full.ShowAll = true
+	// The overlay is sized to its content rather than cut to the screen.
+	full.Width = 0
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(m.theme.Controls).
+		Padding(1, 3)
+	return box.Render(lipgloss.JoinVertical(lipgloss.Left,
+		m.theme.textStyle().Bold(true).Render("Keys"),
+		"",
+		full.View(m.helpKeys()),
+		"",
+		m.theme.textStyle().Render("Press any key to close")))
+}
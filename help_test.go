@@ -0,0 +1,31 @@
+package main
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestHelpOnWinScreen(t *testing.T) {
+	question := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("?")}
+	for _, tt := range []struct {
+		session *Session
+		// typing is whether ? goes into the name being typed.
+		typing bool
+	}{
+		{&Session{KeyFingerprint: "SHA256:new"}, true},
+		{&Session{KeyFingerprint: "SHA256:known", Name: "ann"}, false},
+		{&Session{}, false},
+	} {
+		m := newTestGame(t)
+		m.KeyMap = Keys
+		m.session = tt.session
+		m.state = Won
+
+		model, _ := m.Update(question)
+		got := model.(GameModel)
+		if got.help.ShowAll == tt.typing || (got.playerName == "?") != tt.typing {
+			t.Errorf("%+v: help shown %v, name %q; want ? typed %v", *tt.session, got.help.ShowAll, got.playerName, tt.typing)
+		}
+	}
+}
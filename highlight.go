@@ -0,0 +1,23 @@
+package main
+
+// cursorOnBoard reports whether the cursor is on a cell; it is moved off
+// the board when a board is shown without one.
+func (m GameModel) cursorOnBoard() bool {
+	return m.cursor.row >= 0 && m.cursor.row < sudokuLen && m.cursor.col >= 0 && m.cursor.col < sudokuLen
+}
+
+// seesCursor reports whether c shares a row, column or box with the
+// cursor's cell.
+func (m GameModel) seesCursor(c coordinate) bool {
+	return m.cursorOnBoard() && sees(m.cursor, c)
+}
+
+// matchesCursor reports whether c is another cell holding the same digit as
+// the cursor's cell.
+func (m GameModel) matchesCursor(c coordinate) bool {
+	if !m.cursorOnBoard() || c == m.cursor {
+		return false
+	}
+	digit := m.board[m.cursor.row][m.cursor.col]
+	return digit != 0 && m.board[c.row][c.col] == digit
+}
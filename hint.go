@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// hint is the next deduction suggested to the player, with the cells that
+// should be highlighted while it is shown.
+type hint struct {
+	cells       []coordinate
+	explanation string
+}
+
+func (h hint) equal(other hint) bool {
+	return h.explanation == other.explanation && slices.Equal(h.cells, other.cells)
+}
+
+// nextHint asks the logical solver for the next placement reachable from the
+// current board. Mistakes are pointed out first, since no deduction can be
+// trusted while the board contradicts the solution.
+func (m *GameModel) nextHint() hint {
+	for i := 0; i < sudokuLen; i++ {
+		for j := 0; j < sudokuLen; j++ {
+			if m.board[i][j] != 0 && m.board[i][j] != m.solution[i][j] {
+				return hint{
+					cells:       []coordinate{{i, j}},
+					explanation: fmt.Sprintf("The %d in row %d, column %d is wrong — clear it first", m.board[i][j], i+1, j+1),
+				}
+			}
+		}
+	}
+
+	s := newLogicSolver(m.board)
+	var cells []coordinate
+	var unlockedBy []string
+	for !s.solved() {
+		st, ok := s.nextStep()
+		if !ok {
+			break
+		}
+		cells = append(cells, st.cells...)
+		if len(st.placements) > 0 {
+			explanation := st.explanation
+			if len(unlockedBy) > 0 {
+				explanation += fmt.Sprintf(" (after %s)", strings.Join(unlockedBy, ", "))
+			}
+			return hint{cells: cells, explanation: explanation}
+		}
+		name := strings.ToLower(st.technique.String())
+		if len(unlockedBy) == 0 || unlockedBy[len(unlockedBy)-1] != name {
+			unlockedBy = append(unlockedBy, name)
+		}
+		s.apply(st)
+	}
+	return hint{explanation: "No logical deduction found from here"}
+}
+
+func (h *hint) highlights(c coordinate) bool {
+	if h == nil {
+		return false
+	}
+	for _, hc := range h.cells {
+		if hc == c {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package main
+
+// cellChange records a single cell's digit and notes before and after an
+// edit.
+type cellChange struct {
+	coordinate
+	oldValue, newValue int
+	oldNotes, newNotes candidates
+}
+
+// move is one undoable player action. A single action may touch many cells,
+// e.g. placing a digit that also removes notes from its peers, or clearing
+// the whole board.
+type move []cellChange
+
+// record runs edit and, if it changed the board or notes, pushes the change
+// onto the undo history and discards anything that could have been redone.
+func (m *GameModel) record(edit func()) {
+	board, notes := m.board, m.notes
+	edit()
+
+	var mv move
+	for i := 0; i < sudokuLen; i++ {
+		for j := 0; j < sudokuLen; j++ {
+			if board[i][j] != m.board[i][j] || notes[i][j] != m.notes[i][j] {
+				mv = append(mv, cellChange{
+					coordinate: coordinate{i, j},
+					oldValue:   board[i][j],
+					newValue:   m.board[i][j],
+					oldNotes:   notes[i][j],
+					newNotes:   m.notes[i][j],
+				})
+			}
+		}
+	}
+	if len(mv) > 0 {
+		m.history = append(m.history, mv)
+		m.future = nil
+		m.moves++
+		m.saveProgress()
+		m.shareMove(mv, true)
+	}
+}
+
+func (m *GameModel) undo() {
+	if len(m.history) == 0 {
+		return
+	}
+	mv := m.history[len(m.history)-1]
+	m.history = m.history[:len(m.history)-1]
+	for _, c := range mv {
+		m.board[c.row][c.col] = c.oldValue
+		m.notes[c.row][c.col] = c.oldNotes
+	}
+	m.future = append(m.future, mv)
+	m.refreshState()
+	m.saveProgress()
+	m.shareMove(mv, false)
+}
+
+func (m *GameModel) redo() {
+	if len(m.future) == 0 {
+		return
+	}
+	mv := m.future[len(m.future)-1]
+	m.future = m.future[:len(m.future)-1]
+	for _, c := range mv {
+		m.board[c.row][c.col] = c.newValue
+		m.notes[c.row][c.col] = c.newNotes
+	}
+	m.history = append(m.history, mv)
+	m.moves++
+	m.refreshState()
+	m.saveProgress()
+	m.shareMove(mv, true)
+}
+
+// refreshState recomputes the derived game state after the board was
+// replaced wholesale, the same way set does after a normal edit.
+func (m *GameModel) refreshState() {
+	m.hint = nil
+	m.cellsLeft = 0
+	for i := 0; i < sudokuLen; i++ {
+		for j := 0; j < sudokuLen; j++ {
+			if m.board[i][j] == 0 {
+				m.cellsLeft++
+			}
+		}
+	}
+
+	if m.cellsLeft == 0 {
+		m.updateErrCoordinates()
+		m.updateGameState()
+	} else {
+		m.errCoordinates = make(map[coordinate]bool)
+		m.originalErrCoordinates = make(map[coordinate]bool)
+		m.remainingErrCoordinates = make(map[coordinate]bool)
+		m.state = Playing
+	}
+}
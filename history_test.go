@@ -0,0 +1,136 @@
+package main
+
+import "testing"
+
+const (
+	testPuzzle   = "53..7....6..195....98....6.8...6...34..8.3..17...2...6.6....28....419..5....8..79"
+	testSolution = "534678912672195348198342567859761423426853791713924856961537284287419635345286179"
+)
+
+// newTestGame starts a game of testPuzzle without a session, so nothing is
+// saved to disk.
+func newTestGame(t *testing.T) *GameModel {
+	t.Helper()
+	givens := testGrid(t, testPuzzle)
+	m := &GameModel{
+		board:           givens,
+		initialBoard:    givens,
+		solution:        testGrid(t, testSolution),
+		autoRemoveNotes: true,
+	}
+	m.refreshState()
+	return m
+}
+
+func TestUndoRedoRestoresPeerNotes(t *testing.T) {
+	m := newTestGame(t)
+	// (0,3) and (1,1) see (0,2); (4,4) doesn't.
+	for _, c := range []coordinate{{0, 3}, {1, 1}, {4, 4}} {
+		m.record(func() { m.toggleNote(c.row, c.col, 4) })
+	}
+	cellsLeft := m.cellsLeft
+	notes := m.notes
+
+	m.record(func() { m.set(0, 2, 4) })
+	if m.notes[0][3].has(4) || m.notes[1][1].has(4) || !m.notes[4][4].has(4) {
+		t.Fatal("placing the 4 didn't remove it from exactly the peers' notes")
+	}
+	if len(m.history) != 4 {
+		t.Fatalf("history has %d moves; want 3 notes and the digit", len(m.history))
+	}
+
+	m.undo()
+	if m.board[0][2] != 0 || m.notes != notes || m.cellsLeft != cellsLeft {
+		t.Fatal("undo didn't restore the digit's cell and its peers' notes")
+	}
+
+	m.redo()
+	if m.board[0][2] != 4 || m.notes[0][3].has(4) || m.notes[1][1].has(4) || m.cellsLeft != cellsLeft-1 {
+		t.Fatal("redo didn't place the digit and remove the notes again")
+	}
+
+	for len(m.history) > 0 {
+		m.undo()
+	}
+	if m.notes != ([sudokuLen][sudokuLen]candidates{}) || m.board != m.initialBoard {
+		t.Fatal("undoing everything didn't return to the start")
+	}
+}
+
+func TestClearAllCellsIsOneMove(t *testing.T) {
+	m := newTestGame(t)
+	m.record(func() { m.set(0, 2, 4) })
+	m.record(func() { m.set(0, 3, 9) })
+	m.record(func() { m.toggleNote(8, 0, 3) })
+	before := *m
+
+	m.record(m.clearAllCells)
+	if m.board != m.initialBoard || m.notes[8][0] != 0 {
+		t.Fatal("clearing all cells left digits or notes behind")
+	}
+	if len(m.history) != len(before.history)+1 {
+		t.Fatalf("clearing all cells added %d moves; want 1", len(m.history)-len(before.history))
+	}
+
+	m.undo()
+	if m.board != before.board || m.notes != before.notes || m.cellsLeft != before.cellsLeft {
+		t.Fatal("one undo didn't bring back everything the clear removed")
+	}
+}
+
+func TestNewEditDiscardsRedo(t *testing.T) {
+	m := newTestGame(t)
+	m.record(func() { m.set(0, 2, 4) })
+	m.record(func() { m.set(0, 3, 6) })
+	m.undo()
+	if len(m.future) != 1 {
+		t.Fatalf("future has %d moves after an undo; want 1", len(m.future))
+	}
+
+	m.record(func() { m.set(0, 5, 8) })
+	if len(m.future) != 0 {
+		t.Fatal("a new edit kept the moves it replaced")
+	}
+	m.redo()
+	if m.board[0][3] != 0 || m.board[0][5] != 8 {
+		t.Fatal("redo replayed a move that a new edit replaced")
+	}
+}
+
+func TestEditsThatChangeNothingAreNotRecorded(t *testing.T) {
+	m := newTestGame(t)
+	m.record(func() { m.set(0, 0, 1) })        // a given
+	m.record(func() { m.toggleNote(0, 1, 2) }) // also a given
+	m.record(func() { m.clear(0, 2) })         // already empty
+	m.record(func() { m.set(0, 2, 4) })
+	m.record(func() { m.set(0, 2, 4) })        // the same digit again
+	m.record(func() { m.toggleNote(0, 2, 5) }) // a filled cell
+	if len(m.history) != 1 {
+		t.Fatalf("history has %d moves; want only the one that changed the board", len(m.history))
+	}
+
+	m.undo()
+	m.undo()
+	m.redo()
+	m.redo()
+	if m.board[0][2] != 4 || len(m.history) != 1 || len(m.future) != 0 {
+		t.Fatal("undo and redo past the ends of the history changed the game")
+	}
+}
+
+// Undoing doesn't take back the moves counted, and redoing one is another.
+func TestMovesCounter(t *testing.T) {
+	m := newTestGame(t)
+	m.record(func() { m.set(0, 2, 4) })
+	m.record(func() { m.set(0, 3, 6) })
+	m.record(func() { m.set(0, 0, 1) }) // a given, so not a move
+	m.undo()
+	m.undo()
+	if m.moves != 2 {
+		t.Fatalf("%d moves after two edits were undone; want 2", m.moves)
+	}
+	m.redo()
+	if m.moves != 3 {
+		t.Fatalf("%d moves after a redo; want 3", m.moves)
+	}
+}
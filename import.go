@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ImportModel lets the player paste a puzzle in any format parsePuzzle
+// understands and play it.
+type ImportModel struct {
+	input         textarea.Model
+	err           string
+	width, height int
+	session       *Session
+	help          help.Model
+}
+
+func NewImportModel(width, height int, session *Session) *ImportModel {
+	input := textarea.New()
+	input.Placeholder = "Paste an 81-character line, an .sdk grid or JSON"
+	input.CharLimit = 2000
+	// The textarea deletes forward with ctrl+d too, which is taken by the
+	// Submit key.
+	input.KeyMap.DeleteCharacterForward.SetKeys("delete")
+	input.SetWidth(84)
+	input.SetHeight(12)
+	input.Focus()
+	return &ImportModel{
+		input:   input,
+		width:   width,
+		height:  height,
+		session: session,
+		help:    newHelp(session.Theme(), width),
+	}
+}
+
+func (m ImportModel) Init() tea.Cmd {
+	return textarea.Blink
+}
+
+func (m ImportModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		keys := m.session.KeyMap()
+		switch {
+		case key.Matches(msg, keys.Cancel):
+			return NewMenuModel(m.width, m.height, m.session), nil
+		case key.Matches(msg, keys.ForceQuit):
+			return m, tea.Quit
+		case key.Matches(msg, keys.Submit):
+			model, err := NewImportedGameModel(m.width, m.height, m.input.Value(), m.session)
+			if err != nil {
+				m.err = err.Error()
+				return m, nil
+			}
+			return model, model.Init()
+		}
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.help.Width = msg.Width
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m ImportModel) View() string {
+	var s strings.Builder
+	s.WriteString(lipgloss.NewStyle().Bold(true).Render("Enter puzzle") + "\n\n")
+	s.WriteString(m.input.View() + "\n\n")
+	if m.err != "" {
+		s.WriteString(m.session.Theme().failureStyle().Render(m.err) + "\n")
+	}
+	k := m.session.KeyMap()
+	s.WriteString(m.help.View(bindingHelp{k.Submit, relabel(k.Cancel, "back to menu")}))
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, s.String())
+}
+
+// NewImportedGameModel starts a game from puzzle text. The puzzle must have
+// exactly one solution.
+func NewImportedGameModel(width, height int, text string, session *Session) (*GameModel, error) {
+	givens, progress, err := parsePuzzle(text)
+	if err != nil {
+		return nil, err
+	}
+	solution, err := validatePuzzle(givens)
+	if err != nil {
+		return nil, err
+	}
+	m := newCustomGameModel(width, height, givens, solution, session)
+	// Any wrong digits in the progress are kept; the player finds out about
+	// them the usual way.
+	m.board = progress
+	m.refreshState()
+	return m, nil
+}
+
+// newCustomGameModel starts a game from givens that didn't come from our
+// generator. Its difficulty is graded from the techniques it needs, and it
+// is unranked since the puzzle could have been picked to be easy.
+func newCustomGameModel(width, height int, givens, solution [sudokuLen][sudokuLen]int, session *Session) *GameModel {
+	difficulty := Hard
+	if grade, solved := gradePuzzle(givens); solved {
+		difficulty = grade.Difficulty()
+	}
+	m := newGameModel(width, height, difficulty, givens, solution, session)
+	m.unranked = "it is a custom puzzle"
+	return m
+}
+
+// exportText is the puzzle in every format, printed outside the alt screen
+// so that long lines are left for the terminal to wrap and copy whole.
+func (m GameModel) exportText() string {
+	var s strings.Builder
+	s.WriteString(fmt.Sprintf("Puzzle:\n%s\n\n", formatLine(m.initialBoard)))
+	s.WriteString(fmt.Sprintf("Progress:\n%s\n\n", formatLine(m.board)))
+	s.WriteString(fmt.Sprintf(".sdk:\n%s\n\n", formatSDK(m.initialBoard)))
+	s.WriteString(fmt.Sprintf("JSON:\n%s\n", formatJSON(m.initialBoard, m.board)))
+	return s.String()
+}
+
+// renderExport is shown below the printed export. It isn't placed on the
+// whole screen, which would scroll the export out of sight.
+func (m GameModel) renderExport() string {
+	return lipgloss.NewStyle().Bold(true).Render("Export puzzle") + "\n\n" +
+		"The puzzle is printed above, ready to copy.\n" +
+		"Press any key to return to the game"
+}
@@ -1,38 +1,89 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/BurntSushi/toml"
 	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
 )
 
+const keyMapsFileName = "sudoku_keymaps.toml"
+
 type KeyMap struct {
+	Name            string
 	Up              key.Binding
 	Down            key.Binding
 	Left            key.Binding
 	Right           key.Binding
 	Help            key.Binding
 	Quit            key.Binding
+	ForceQuit       key.Binding
 	Clear           key.Binding
 	Number          key.Binding
 	Menu            key.Binding
 	ViewLeaderboard key.Binding
 	AdminMode       key.Binding
 	ClearAll        key.Binding
+	Hint            key.Binding
+	Notes           key.Binding
+	AutoRemoveNotes key.Binding
+	Undo            key.Binding
+	Redo            key.Binding
+	Pause           key.Binding
+	Export          key.Binding
+	Select          key.Binding
+	Back            key.Binding
+	Confirm         key.Binding
+	Cancel          key.Binding
+	Submit          key.Binding
+	Delete          key.Binding
+	Filter          key.Binding
 }
 
+// ShortHelp and FullHelp are the help shown while playing.
 func (k KeyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.Help, k.Quit}
+	return []key.Binding{k.move(), k.Number, k.Clear, k.Notes, k.Hint, k.Undo, k.Menu, k.Help, k.Quit}
 }
 
 func (k KeyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
-		{k.Up, k.Down},
-		{k.Left, k.Right},
-		{k.Help, k.Quit},
-		{k.Number, k.Clear},
+		{k.Up, k.Down, k.Left, k.Right, k.Number, k.Clear, k.ClearAll},
+		{k.Notes, k.AutoRemoveNotes, k.Hint, k.Undo, k.Redo},
+		{k.Pause, k.Export, k.Menu, k.ViewLeaderboard, k.Help, k.Quit},
+	}
+}
+
+// move stands for all four movement bindings in the short help.
+func (k KeyMap) move() key.Binding {
+	var keys []string
+	var shown string
+	for _, b := range []key.Binding{k.Up, k.Down, k.Left, k.Right} {
+		keys = append(keys, b.Keys()...)
+		if len(b.Keys()) > 0 {
+			shown += formatKeys(b.Keys()[:1])
+		}
 	}
+	return key.NewBinding(key.WithKeys(keys...), key.WithHelp(shown, "move"))
+}
+
+// digit returns the digit a Number key enters, which is its position among
+// the Number keys.
+func (k KeyMap) digit(msg tea.KeyMsg) (int, bool) {
+	for i, s := range k.Number.Keys() {
+		if msg.String() == s {
+			return i + 1, true
+		}
+	}
+	return 0, false
 }
 
 var Keys = KeyMap{
+	Name: "default",
 	Up: key.NewBinding(
 		key.WithKeys("up", "k"),
 		key.WithHelp("↑/k", "move up"),
@@ -57,15 +108,22 @@ var Keys = KeyMap{
 		key.WithKeys("q", "esc"),
 		key.WithHelp("q/esc", "quit"),
 	),
+	ForceQuit: key.NewBinding(
+		key.WithKeys("ctrl+c"),
+		key.WithHelp("ctrl+c", "quit"),
+	),
 	Clear: key.NewBinding(
 		key.WithKeys("backspace", " "),
-		key.WithHelp("↵/space", "clear cell"),
+		key.WithHelp("⌫/space", "clear cell"),
 	),
 	Number: key.NewBinding(
 		key.WithKeys("1", "2", "3", "4", "5", "6", "7", "8", "9"),
-		key.WithHelp("", "set cell to number"),
+		key.WithHelp("1-9", "set cell to number"),
+	),
+	Menu: key.NewBinding(
+		key.WithKeys("m"),
+		key.WithHelp("m", "menu"),
 	),
-	Menu: key.NewBinding(key.WithKeys("m")),
 	ViewLeaderboard: key.NewBinding(
 		key.WithKeys("b"),
 		key.WithHelp("b", "view leaderboard"),
@@ -78,4 +136,358 @@ var Keys = KeyMap{
 		key.WithKeys("C"),
 		key.WithHelp("C", "clear all modifiable cells"),
 	),
+	Hint: key.NewBinding(
+		key.WithKeys("H"),
+		key.WithHelp("H", "show a hint"),
+	),
+	Notes: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "toggle notes mode"),
+	),
+	AutoRemoveNotes: key.NewBinding(
+		key.WithKeys("N"),
+		key.WithHelp("N", "toggle auto-removing notes"),
+	),
+	Undo: key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "undo"),
+	),
+	Redo: key.NewBinding(
+		key.WithKeys("ctrl+r"),
+		key.WithHelp("ctrl+r", "redo"),
+	),
+	Pause: key.NewBinding(
+		key.WithKeys("p"),
+		key.WithHelp("p", "pause/resume"),
+	),
+	Export: key.NewBinding(
+		key.WithKeys("E"),
+		key.WithHelp("E", "export puzzle"),
+	),
+	Select: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "select"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("esc", "q"),
+		key.WithHelp("esc/q", "back"),
+	),
+	Confirm: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "confirm"),
+	),
+	Cancel: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "cancel"),
+	),
+	// ctrl+d finishes multi-line input, like end of file in a shell. ctrl+s
+	// would be more familiar but many terminals still treat it as XOFF.
+	Submit: key.NewBinding(
+		key.WithKeys("ctrl+d"),
+		key.WithHelp("ctrl+d", "play"),
+	),
+	Delete: key.NewBinding(
+		key.WithKeys("d"),
+		key.WithHelp("d", "delete entry"),
+	),
+	Filter: key.NewBinding(
+		key.WithKeys("f"),
+		key.WithHelp("f", "toggle no-mistake runs"),
+	),
+}
+
+// keyMapPresets are the built-in key maps players can choose from.
+var keyMapPresets = []KeyMap{
+	Keys,
+	withKeys(Keys, "vim", map[string][]string{
+		"up":    {"k"},
+		"down":  {"j"},
+		"left":  {"h"},
+		"right": {"l"},
+		"clear": {"x", "backspace"},
+	}),
+	withKeys(Keys, "wasd", map[string][]string{
+		"up":    {"w", "up"},
+		"down":  {"s", "down"},
+		"left":  {"a", "left"},
+		"right": {"d", "right"},
+	}),
+	// numpad keeps everything a game needs on the number pad, so the other
+	// hand is free.
+	withKeys(Keys, "numpad", map[string][]string{
+		"up":    {"up"},
+		"down":  {"down"},
+		"left":  {"left"},
+		"right": {"right"},
+		"clear": {"0", ".", "backspace"},
+		"notes": {"+"},
+		"undo":  {"-"},
+		"redo":  {"*"},
+		"hint":  {"/"},
+	}),
+}
+
+// keyAction names a binding for keymap files.
+type keyAction struct {
+	name    string
+	binding func(*KeyMap) *key.Binding
+}
+
+var keyActions = []keyAction{
+	{"up", func(k *KeyMap) *key.Binding { return &k.Up }},
+	{"down", func(k *KeyMap) *key.Binding { return &k.Down }},
+	{"left", func(k *KeyMap) *key.Binding { return &k.Left }},
+	{"right", func(k *KeyMap) *key.Binding { return &k.Right }},
+	{"help", func(k *KeyMap) *key.Binding { return &k.Help }},
+	{"quit", func(k *KeyMap) *key.Binding { return &k.Quit }},
+	{"force_quit", func(k *KeyMap) *key.Binding { return &k.ForceQuit }},
+	{"clear", func(k *KeyMap) *key.Binding { return &k.Clear }},
+	{"number", func(k *KeyMap) *key.Binding { return &k.Number }},
+	{"menu", func(k *KeyMap) *key.Binding { return &k.Menu }},
+	{"leaderboard", func(k *KeyMap) *key.Binding { return &k.ViewLeaderboard }},
+	{"admin", func(k *KeyMap) *key.Binding { return &k.AdminMode }},
+	{"clear_all", func(k *KeyMap) *key.Binding { return &k.ClearAll }},
+	{"hint", func(k *KeyMap) *key.Binding { return &k.Hint }},
+	{"notes", func(k *KeyMap) *key.Binding { return &k.Notes }},
+	{"auto_remove_notes", func(k *KeyMap) *key.Binding { return &k.AutoRemoveNotes }},
+	{"undo", func(k *KeyMap) *key.Binding { return &k.Undo }},
+	{"redo", func(k *KeyMap) *key.Binding { return &k.Redo }},
+	{"pause", func(k *KeyMap) *key.Binding { return &k.Pause }},
+	{"export", func(k *KeyMap) *key.Binding { return &k.Export }},
+	{"select", func(k *KeyMap) *key.Binding { return &k.Select }},
+	{"back", func(k *KeyMap) *key.Binding { return &k.Back }},
+	{"confirm", func(k *KeyMap) *key.Binding { return &k.Confirm }},
+	{"cancel", func(k *KeyMap) *key.Binding { return &k.Cancel }},
+	{"submit", func(k *KeyMap) *key.Binding { return &k.Submit }},
+	{"delete", func(k *KeyMap) *key.Binding { return &k.Delete }},
+	{"filter", func(k *KeyMap) *key.Binding { return &k.Filter }},
+}
+
+func findKeyAction(name string) (keyAction, bool) {
+	for _, a := range keyActions {
+		if a.name == name {
+			return a, true
+		}
+	}
+	return keyAction{}, false
+}
+
+// keyContext lists actions that are active at the same time, and so can't
+// share a key. The same key can do different things in different contexts.
+type keyContext struct {
+	name    string
+	actions []string
+	// typing is set for contexts where text is being entered, whose keys
+	// can't be ones that type a character.
+	typing bool
+}
+
+var keyContexts = []keyContext{
+	{name: "game", actions: []string{"up", "down", "left", "right", "number", "clear", "clear_all", "hint", "notes",
+		"auto_remove_notes", "undo", "redo", "pause", "export", "menu", "leaderboard", "quit", "help", "force_quit"}},
+	{name: "paused", actions: []string{"pause", "quit", "help", "force_quit"}},
+	{name: "main menu", actions: []string{"up", "down", "select", "quit", "help", "force_quit"}},
+	{name: "game menu", actions: []string{"up", "down", "select", "help", "force_quit"}},
+	{name: "settings", actions: []string{"up", "down", "left", "right", "select", "back", "help", "force_quit"}},
+	{name: "editor", actions: []string{"up", "down", "left", "right", "number", "clear", "select", "back", "help", "force_quit"}},
+	{name: "leaderboard", actions: []string{"admin", "filter", "back", "help", "force_quit"}},
+	{name: "admin", actions: []string{"up", "down", "delete", "back", "help", "force_quit"}},
+	{name: "spectator", actions: []string{"up", "down", "select", "back", "help", "force_quit"}},
+	{name: "lobby", actions: []string{"up", "down", "select", "back", "help", "force_quit"}},
+	{name: "game over", actions: []string{"select", "back", "help", "force_quit"}},
+	{name: "text entry", actions: []string{"confirm", "cancel", "submit", "force_quit"}, typing: true},
+}
+
+// withKeys returns a copy of base named name, with the keys of the given
+// actions replaced. Their help shows the new keys.
+func withKeys(base KeyMap, name string, keys map[string][]string) KeyMap {
+	k := base
+	k.Name = name
+	for _, a := range keyActions {
+		if ks, ok := keys[a.name]; ok {
+			b := a.binding(&k)
+			*b = key.NewBinding(key.WithKeys(ks...), key.WithHelp(formatKeys(ks), b.Help().Desc))
+		}
+	}
+	return k
+}
+
+var keySymbols = map[string]string{
+	"up":        "↑",
+	"down":      "↓",
+	"left":      "←",
+	"right":     "→",
+	"backspace": "⌫",
+	" ":         "space",
+}
+
+// formatKeys shows keys the way the built-in help does, e.g. "↑/k".
+func formatKeys(keys []string) string {
+	shown := make([]string, len(keys))
+	for i, k := range keys {
+		shown[i] = k
+		if s, ok := keySymbols[k]; ok {
+			shown[i] = s
+		}
+	}
+	return strings.Join(shown, "/")
+}
+
+// validate reports every key bound to two actions of one context, and keys
+// that can't work where they're used.
+func (k KeyMap) validate() error {
+	var errs []error
+	if n := len(k.Number.Keys()); n != sudokuLen {
+		errs = append(errs, fmt.Errorf("number needs %d keys, one per digit, not %d", sudokuLen, n))
+	}
+	for _, c := range keyContexts {
+		usedBy := map[string]string{}
+		for _, name := range c.actions {
+			a, _ := findKeyAction(name)
+			for _, s := range a.binding(&k).Keys() {
+				if other, ok := usedBy[s]; ok && other != name {
+					errs = append(errs, fmt.Errorf("%q is bound to both %s and %s in the %s", s, other, name, c.name))
+				}
+				usedBy[s] = name
+				if c.typing && utf8.RuneCountInString(s) == 1 {
+					errs = append(errs, fmt.Errorf("%q can't be bound to %s since it types a character in the %s", s, name, c.name))
+				}
+			}
+		}
+	}
+	for i, err := range errs {
+		errs[i] = fmt.Errorf("key map %s: %w", k.Name, err)
+	}
+	return errors.Join(errs...)
+}
+
+// KeyMapRegistry holds the preset key maps followed by those defined in the
+// key maps file. It isn't changed after loading, so it needs no lock.
+type KeyMapRegistry struct {
+	keyMaps []KeyMap
+}
+
+var keyMaps = NewKeyMapRegistry()
+
+func NewKeyMapRegistry() *KeyMapRegistry {
+	return &KeyMapRegistry{keyMaps: append([]KeyMap(nil), keyMapPresets...)}
+}
+
+// LoadKeyMapsFromFile adds the key maps defined in a TOML file to the
+// presets. Each [[keymap]] starts from the key map named by its base key,
+// default if not given, and rebinds the actions it lists, e.g.
+// undo = ["u", "z"]. Every key map is checked for conflicts, so a mistake
+// is found when the server starts rather than mid-game.
+func LoadKeyMapsFromFile(filename string) (*KeyMapRegistry, error) {
+	registry := NewKeyMapRegistry()
+	var file struct {
+		KeyMaps []map[string]any `toml:"keymap"`
+	}
+	_, err := toml.DecodeFile(filename, &file)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	var errs []error
+	for i, entry := range file.KeyMaps {
+		name, _ := entry["name"].(string)
+		baseName, _ := entry["base"].(string)
+		if strings.TrimSpace(name) == "" {
+			errs = append(errs, fmt.Errorf("key map %d: name must not be empty", i+1))
+			continue
+		}
+		base := registry.find(baseName)
+		if baseName == "" {
+			base = &Keys
+		} else if base == nil {
+			errs = append(errs, fmt.Errorf("key map %s: unknown base key map %q", name, baseName))
+			continue
+		}
+
+		keys := map[string][]string{}
+		for action, value := range entry {
+			if action == "name" || action == "base" {
+				continue
+			}
+			if _, ok := findKeyAction(action); !ok {
+				errs = append(errs, fmt.Errorf("key map %s: unknown action %q", name, action))
+				continue
+			}
+			ks, err := keyList(value)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("key map %s: %s: %w", name, action, err))
+				continue
+			}
+			keys[action] = ks
+		}
+		registry.add(withKeys(*base, name, keys))
+	}
+
+	for _, k := range registry.keyMaps {
+		if err := k.validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return registry, nil
+}
+
+// keyList accepts either a single key or a list of keys.
+func keyList(value any) ([]string, error) {
+	switch v := value.(type) {
+	case string:
+		return []string{v}, nil
+	case []any:
+		keys := make([]string, len(v))
+		for i, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, errors.New("keys must be strings")
+			}
+			keys[i] = s
+		}
+		if len(keys) == 0 {
+			return nil, errors.New("at least one key is needed")
+		}
+		return keys, nil
+	}
+	return nil, errors.New("keys must be a string or a list of strings")
+}
+
+func (r *KeyMapRegistry) add(k KeyMap) {
+	for i := range r.keyMaps {
+		if r.keyMaps[i].Name == k.Name {
+			r.keyMaps[i] = k
+			return
+		}
+	}
+	r.keyMaps = append(r.keyMaps, k)
+}
+
+func (r *KeyMapRegistry) find(name string) *KeyMap {
+	for i := range r.keyMaps {
+		if r.keyMaps[i].Name == name {
+			return &r.keyMaps[i]
+		}
+	}
+	return nil
+}
+
+// Get returns the named key map, or the default one if there is no such key
+// map.
+func (r *KeyMapRegistry) Get(name string) KeyMap {
+	if k := r.find(name); k != nil {
+		return *k
+	}
+	return r.keyMaps[0]
+}
+
+func (r *KeyMapRegistry) Names() []string {
+	names := make([]string, len(r.keyMaps))
+	for i, k := range r.keyMaps {
+		names[i] = k.Name
+	}
+	return names
 }
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestKeyMapPresetsValidate(t *testing.T) {
+	for _, k := range keyMapPresets {
+		if err := k.validate(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestKeyMapValidate(t *testing.T) {
+	// The delete key is only read in the leaderboard, so it can share u
+	// with undo.
+	if err := withKeys(Keys, "test", map[string][]string{"delete": {"u"}}).validate(); err != nil {
+		t.Errorf("a key shared by two screens was rejected: %v", err)
+	}
+
+	for want, keys := range map[string]map[string][]string{
+		`"u" is bound to both hint and undo in the game`:                            {"hint": {"u"}},
+		`"5" is bound to both number and clear in the game`:                         {"clear": {"5", "backspace"}},
+		`"s" can't be bound to submit since it types a character in the text entry`: {"submit": {"s"}},
+		"number needs 9 keys, one per digit, not 8":                                 {"number": {"1", "2", "3", "4", "5", "6", "7", "8"}},
+	} {
+		err := withKeys(Keys, "test", keys).validate()
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("validate %v = %v; want %q", keys, err, want)
+		}
+	}
+}
+
+func TestLoadKeyMapsFromFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "keymaps.toml")
+	// The second key map builds on the first. Redefining a preset replaces
+	// it, starting over from the default keys.
+	err := os.WriteFile(filename, []byte(`
+[[keymap]]
+name = "left-handed"
+undo = ["z", "u"]
+
+[[keymap]]
+name = "left-handed-redo"
+base = "left-handed"
+redo = "Z"
+
+[[keymap]]
+name = "vim"
+undo = "U"
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry, err := LoadKeyMapsFromFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k := registry.find("left-handed-redo")
+	if k == nil {
+		t.Fatal("the key map built on another wasn't loaded")
+	}
+	if got := strings.Join(k.Undo.Keys(), " "); got != "z u" {
+		t.Errorf("undo is bound to %q; want the base's z u", got)
+	}
+	if got := k.Redo.Help().Key; got != "Z" {
+		t.Errorf("redo's help shows %q; want Z", got)
+	}
+	if vim := registry.find("vim"); vim == nil || vim.Undo.Keys()[0] != "U" || vim.Up.Keys()[0] != Keys.Up.Keys()[0] {
+		t.Error("redefining vim didn't replace the preset")
+	}
+	if len(registry.keyMaps) != len(keyMapPresets)+2 {
+		t.Errorf("%d key maps loaded; want the presets and 2 more", len(registry.keyMaps))
+	}
+}
+
+func TestLoadKeyMapsFromFileReportsEveryProblem(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "keymaps.toml")
+	err := os.WriteFile(filename, []byte(`
+[[keymap]]
+name = "a"
+base = "missing"
+
+[[keymap]]
+name = "b"
+jump = "j"
+
+[[keymap]]
+name = "c"
+hint = "u"
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = LoadKeyMapsFromFile(filename)
+	for _, want := range []string{`unknown base key map "missing"`, `unknown action "jump"`, "key map c:"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("LoadKeyMapsFromFile error %q; want it to mention %s", err, want)
+		}
+	}
+}
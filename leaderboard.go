@@ -8,10 +8,18 @@ import (
 )
 
 type LeaderboardEntry struct {
-	Name       string        `json:"name"`
-	Time       time.Duration `json:"time"`
-	Difficulty Difficulty    `json:"difficulty"`
-	Date       time.Time     `json:"date"`
+	Name string `json:"name"`
+	// Fingerprint is the SSH public key fingerprint of the player who posted
+	// the score.
+	Fingerprint string        `json:"fingerprint,omitempty"`
+	Time        time.Duration `json:"time"`
+	Difficulty  Difficulty    `json:"difficulty"`
+	Hints       int           `json:"hints"`
+	Assist      AssistLevel   `json:"assist"`
+	Mistakes    int           `json:"mistakes"`
+	Date        time.Time     `json:"date"`
+	// Daily is the date of the daily puzzle the score was set on, if any.
+	Daily string `json:"daily,omitempty"`
 }
 
 type Leaderboard struct {
@@ -24,16 +32,11 @@ func NewLeaderboard() *Leaderboard {
 	}
 }
 
-func (l *Leaderboard) AddEntry(name string, duration time.Duration, difficulty Difficulty) {
-	entry := LeaderboardEntry{
-		Name:       name,
-		Time:       duration,
-		Difficulty: difficulty,
-		Date:       time.Now(),
+func (l *Leaderboard) AddEntry(entry LeaderboardEntry) {
+	if entry.Date.IsZero() {
+		entry.Date = time.Now()
 	}
 	l.Entries = append(l.Entries, entry)
-	// Sort entries if needed
-	l.SaveToFile("sudoku_leaderboard.json")
 	sort.Slice(l.Entries, func(i, j int) bool {
 		return l.Entries[i].Time < l.Entries[j].Time
 	})
@@ -44,7 +47,7 @@ func (l *Leaderboard) SaveToFile(filename string) error {
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(filename, data, 0644)
+	return writeFileAtomic(filename, data, 0644)
 }
 
 func (l *Leaderboard) DeleteEntry(index int) {
@@ -57,6 +60,24 @@ func (l *Leaderboard) DeleteEntry(index int) {
 	})
 }
 
+// indexOf returns the index of the entry matching entry, or -1.
+func (l *Leaderboard) indexOf(entry LeaderboardEntry) int {
+	for i, e := range l.Entries {
+		if sameEntry(e, entry) {
+			return i
+		}
+	}
+	return -1
+}
+
+func sameEntry(a, b LeaderboardEntry) bool {
+	return a.Name == b.Name &&
+		a.Fingerprint == b.Fingerprint &&
+		a.Time == b.Time &&
+		a.Difficulty == b.Difficulty &&
+		a.Date.Equal(b.Date)
+}
+
 func LoadLeaderboardFromFile(filename string) (*Leaderboard, error) {
 	data, err := os.ReadFile(filename)
 	if err != nil {
@@ -75,9 +96,16 @@ func LoadLeaderboardFromFile(filename string) (*Leaderboard, error) {
 }
 
 func (l *Leaderboard) GetTopScores(difficulty Difficulty, limit int) []LeaderboardEntry {
+	return l.GetTopScoresWhere(func(entry LeaderboardEntry) bool {
+		return entry.Difficulty == difficulty
+	}, limit)
+}
+
+// GetTopScoresWhere returns the fastest entries for which keep returns true.
+func (l *Leaderboard) GetTopScoresWhere(keep func(LeaderboardEntry) bool, limit int) []LeaderboardEntry {
 	var filteredEntries []LeaderboardEntry
 	for _, entry := range l.Entries {
-		if entry.Difficulty == difficulty {
+		if keep(entry) {
 			filteredEntries = append(filteredEntries, entry)
 		}
 	}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	leaderboardName = "sudoku_leaderboard"
+	leaderboardSize = 10
+)
+
+// leaderboardChangedMsg is sent to every session when a score is added or
+// removed, so that open leaderboard views redraw.
+type leaderboardChangedMsg struct{}
+
+// LeaderboardService is the single leaderboard shared by every session in
+// the process. All reads and writes go through its mutex, so two players
+// winning at the same time can't overwrite each other's scores.
+type LeaderboardService struct {
+	mu    sync.RWMutex
+	board *Leaderboard
+	store LeaderboardStore
+}
+
+// leaderboardService is opened in main from the configured store.
+var leaderboardService *LeaderboardService
+
+func NewLeaderboardService(store LeaderboardStore) (*LeaderboardService, error) {
+	entries, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	board := NewLeaderboard()
+	for _, entry := range entries {
+		board.AddEntry(entry)
+	}
+	return &LeaderboardService{board: board, store: store}, nil
+}
+
+func (s *LeaderboardService) AddEntry(entry LeaderboardEntry) error {
+	if entry.Date.IsZero() {
+		entry.Date = time.Now()
+	}
+
+	// The store goes first so that a score it fails to save isn't shown
+	// until the next restart loses it.
+	s.mu.Lock()
+	err := s.store.Add(entry)
+	if err == nil {
+		s.board.AddEntry(entry)
+	}
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	programs.broadcast(leaderboardChangedMsg{})
+	return nil
+}
+
+// DeleteEntry removes the first entry matching entry.
+func (s *LeaderboardService) DeleteEntry(entry LeaderboardEntry) error {
+	s.mu.Lock()
+	i := s.board.indexOf(entry)
+	if i < 0 {
+		s.mu.Unlock()
+		return nil
+	}
+	err := s.store.Delete(entry)
+	if err == nil {
+		s.board.DeleteEntry(i)
+	}
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	programs.broadcast(leaderboardChangedMsg{})
+	return nil
+}
+
+func (s *LeaderboardService) GetTopScores(difficulty Difficulty, limit int) []LeaderboardEntry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.board.GetTopScores(difficulty, limit)
+}
+
+func (s *LeaderboardService) GetTopScoresWhere(keep func(LeaderboardEntry) bool, limit int) []LeaderboardEntry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.board.GetTopScoresWhere(keep, limit)
+}
+
+func (s *LeaderboardService) Close() error {
+	return s.store.Close()
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+var testLeaderboardBackends = []string{"json", "bolt"}
+
+// openTestLeaderboard returns a function that loads the same leaderboard
+// afresh each time it's called, as a restarted server would. Opening it
+// closes the service opened before, since bolt locks its database.
+func openTestLeaderboard(t *testing.T, backend string) func() *LeaderboardService {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "leaderboard")
+	var last *LeaderboardService
+	t.Cleanup(func() {
+		if last != nil {
+			last.Close()
+		}
+	})
+	return func() *LeaderboardService {
+		if last != nil {
+			if err := last.Close(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		store, err := OpenLeaderboardStore(backend, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if last, err = NewLeaderboardService(store); err != nil {
+			t.Fatal(err)
+		}
+		return last
+	}
+}
+
+var testScoreDate = time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+func TestLeaderboardServiceConcurrentWins(t *testing.T) {
+	for _, backend := range testLeaderboardBackends {
+		t.Run(backend, func(t *testing.T) {
+			testConcurrentWins(t, openTestLeaderboard(t, backend))
+		})
+	}
+}
+
+func testConcurrentWins(t *testing.T, open func() *LeaderboardService) {
+	s := open()
+
+	const players = 20
+	var wg sync.WaitGroup
+	for i := 0; i < players; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := s.AddEntry(LeaderboardEntry{
+				Name:       fmt.Sprintf("player%d", i),
+				Time:       time.Duration(players-i) * time.Minute,
+				Difficulty: Medium,
+				Date:       testScoreDate,
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Every score survives, both in memory and on disk, and comes back
+	// fastest first.
+	for _, s := range []*LeaderboardService{s, open()} {
+		scores := s.GetTopScores(Medium, players)
+		if len(scores) != players {
+			t.Fatalf("%d of %d scores were kept", len(scores), players)
+		}
+		for i := 1; i < len(scores); i++ {
+			if scores[i-1].Time > scores[i].Time {
+				t.Fatalf("scores aren't fastest first: %v before %v", scores[i-1].Time, scores[i].Time)
+			}
+		}
+	}
+}
+
+func TestLeaderboardServiceDeleteEntry(t *testing.T) {
+	for _, backend := range testLeaderboardBackends {
+		t.Run(backend, func(t *testing.T) {
+			testDeleteEntry(t, openTestLeaderboard(t, backend))
+		})
+	}
+}
+
+func testDeleteEntry(t *testing.T, open func() *LeaderboardService) {
+	s := open()
+	keep := LeaderboardEntry{Name: "ann", Fingerprint: "SHA256:a", Time: time.Minute, Difficulty: Easy, Date: testScoreDate}
+	remove := keep
+	remove.Fingerprint = "SHA256:b"
+	for _, e := range []LeaderboardEntry{keep, remove} {
+		if err := s.AddEntry(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Only the entry posted by the other key goes, though the names and
+	// times match.
+	if err := s.DeleteEntry(remove); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteEntry(remove); err != nil {
+		t.Fatalf("deleting a missing entry failed: %v", err)
+	}
+	scores := open().GetTopScores(Easy, leaderboardSize)
+	if len(scores) != 1 || scores[0].Fingerprint != keep.Fingerprint {
+		t.Fatalf("after the restart the scores are %+v; want only %+v", scores, keep)
+	}
+}
+
+// When the store can't save a change, neither the service nor the store
+// keeps it, so what players see matches what a restart would load.
+func TestLeaderboardServiceKeepsToWhatWasSaved(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "gone")
+	store := &JSONLeaderboardStore{filename: filepath.Join(dir, "leaderboard.json")}
+	s, err := NewLeaderboardService(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lost := LeaderboardEntry{Name: "ann", Time: time.Minute, Difficulty: Easy, Date: testScoreDate}
+	if err := s.AddEntry(lost); err == nil {
+		t.Fatal("a score was added to a file that can't be written")
+	}
+	if scores := s.GetTopScores(Easy, leaderboardSize); len(scores) != 0 {
+		t.Fatalf("the unsaved score is shown: %+v", scores)
+	}
+
+	os.Mkdir(dir, 0o755)
+	kept := LeaderboardEntry{Name: "bob", Time: 2 * time.Minute, Difficulty: Easy, Date: testScoreDate}
+	if err := s.AddEntry(kept); err != nil {
+		t.Fatal(err)
+	}
+	os.RemoveAll(dir)
+	if err := s.DeleteEntry(kept); err == nil {
+		t.Fatal("a score was deleted from a file that can't be written")
+	}
+	if scores := s.GetTopScores(Easy, leaderboardSize); len(scores) != 1 || scores[0].Name != "bob" {
+		t.Fatalf("after the failed delete the scores are %+v; want only bob's", scores)
+	}
+
+	// The store didn't keep the failed score to save with the next change.
+	os.Mkdir(dir, 0o755)
+	if err := s.AddEntry(LeaderboardEntry{Name: "cat", Time: 3 * time.Minute, Difficulty: Easy, Date: testScoreDate}); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := (&JSONLeaderboardStore{filename: store.filename}).Load()
+	if err != nil || len(entries) != 2 || entries[0].Name != "bob" || entries[1].Name != "cat" {
+		t.Errorf("the file holds %+v, %v; want bob's and cat's scores", entries, err)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// LeaderboardStore persists leaderboard entries. The LeaderboardService keeps
+// its own copy in memory for queries and only calls the store to load
+// entries at startup and to record changes.
+type LeaderboardStore interface {
+	Load() ([]LeaderboardEntry, error)
+	Add(entry LeaderboardEntry) error
+	Delete(entry LeaderboardEntry) error
+	Close() error
+}
+
+// OpenLeaderboardStore opens the named leaderboard with the given backend,
+// either "json" or "bolt".
+func OpenLeaderboardStore(backend, name string) (LeaderboardStore, error) {
+	switch backend {
+	case "json":
+		return &JSONLeaderboardStore{filename: name + ".json"}, nil
+	case "bolt":
+		return OpenBoltLeaderboardStore(name + ".db")
+	default:
+		return nil, fmt.Errorf("unknown leaderboard store %q", backend)
+	}
+}
+
+// JSONLeaderboardStore keeps the whole leaderboard in one JSON file, which is
+// rewritten on every change.
+type JSONLeaderboardStore struct {
+	filename string
+	board    *Leaderboard
+}
+
+func (s *JSONLeaderboardStore) Load() ([]LeaderboardEntry, error) {
+	board, err := LoadLeaderboardFromFile(s.filename)
+	if err != nil {
+		return nil, err
+	}
+	s.board = board
+	return append([]LeaderboardEntry(nil), board.Entries...), nil
+}
+
+// Add and Delete undo their change to the board when the file can't be
+// written, so that the next change doesn't save it after all.
+func (s *JSONLeaderboardStore) Add(entry LeaderboardEntry) error {
+	s.board.AddEntry(entry)
+	if err := s.board.SaveToFile(s.filename); err != nil {
+		s.board.DeleteEntry(s.board.indexOf(entry))
+		return err
+	}
+	return nil
+}
+
+func (s *JSONLeaderboardStore) Delete(entry LeaderboardEntry) error {
+	i := s.board.indexOf(entry)
+	if i < 0 {
+		return nil
+	}
+	s.board.DeleteEntry(i)
+	if err := s.board.SaveToFile(s.filename); err != nil {
+		s.board.AddEntry(entry)
+		return err
+	}
+	return nil
+}
+
+func (s *JSONLeaderboardStore) Close() error {
+	return nil
+}
+
+var leaderboardBucket = []byte("entries")
+
+// BoltLeaderboardStore keeps one record per entry in a bbolt database, so
+// adding a score doesn't rewrite the scores that came before it.
+type BoltLeaderboardStore struct {
+	db *bolt.DB
+}
+
+func OpenBoltLeaderboardStore(filename string) (*BoltLeaderboardStore, error) {
+	db, err := bolt.Open(filename, 0644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(leaderboardBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &BoltLeaderboardStore{db: db}, nil
+}
+
+func (s *BoltLeaderboardStore) Load() ([]LeaderboardEntry, error) {
+	var entries []LeaderboardEntry
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(leaderboardBucket).ForEach(func(_, v []byte) error {
+			var entry LeaderboardEntry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				return err
+			}
+			entries = append(entries, entry)
+			return nil
+		})
+	})
+	return entries, err
+}
+
+func (s *BoltLeaderboardStore) Add(entry LeaderboardEntry) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(leaderboardBucket)
+		id, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, id)
+		return b.Put(key, data)
+	})
+}
+
+func (s *BoltLeaderboardStore) Delete(entry LeaderboardEntry) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		c := tx.Bucket(leaderboardBucket).Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			var e LeaderboardEntry
+			if err := json.Unmarshal(v, &e); err != nil {
+				return err
+			}
+			if sameEntry(e, entry) {
+				return c.Delete()
+			}
+		}
+		return nil
+	})
+}
+
+func (s *BoltLeaderboardStore) Close() error {
+	return s.db.Close()
+}
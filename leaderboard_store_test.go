@@ -0,0 +1,56 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Two wins can tie on every field, as when a player posts the same time twice
+// in the same second. Deleting one must leave the other.
+func TestLeaderboardStoreDeletesOneOfIdenticalEntries(t *testing.T) {
+	for _, backend := range testLeaderboardBackends {
+		t.Run(backend, func(t *testing.T) {
+			name := filepath.Join(t.TempDir(), "leaderboard")
+			entry := LeaderboardEntry{Name: "ann", Time: 90 * time.Second, Difficulty: Hard, Hints: 2, Date: testScoreDate}
+
+			store, err := OpenLeaderboardStore(backend, name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.Load(); err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 2; i++ {
+				if err := store.Add(entry); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := store.Delete(entry); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			store, err = OpenLeaderboardStore(backend, name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer store.Close()
+			entries, err := store.Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 || !sameEntry(entries[0], entry) || entries[0].Hints != entry.Hints {
+				t.Fatalf("reloaded %+v; want just %+v", entries, entry)
+			}
+		})
+	}
+}
+
+func TestOpenLeaderboardStoreUnknownBackend(t *testing.T) {
+	if _, err := OpenLeaderboardStore("sqlite", filepath.Join(t.TempDir(), "leaderboard")); err == nil {
+		t.Fatal("opened a store with a backend that doesn't exist")
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// LobbyModel is where players open multiplayer rooms and join each other's.
+// Once in a room, they wait there until its host starts the game.
+type LobbyModel struct {
+	width, height int
+	session       *Session
+	// open is the joinable rooms, listed after a choice per mode and
+	// difficulty for opening a new one and followed by a choice to go back.
+	open   []roomStatus
+	cursor int
+	// room is the room the player is waiting in, if any.
+	room *roomStatus
+	err  string
+	help help.Model
+}
+
+func NewLobbyModel(width, height int, session *Session) *LobbyModel {
+	return &LobbyModel{
+		width:   width,
+		height:  height,
+		session: session,
+		open:    roomHub.Open(),
+		help:    newHelp(session.Theme(), width),
+	}
+}
+
+func (m LobbyModel) Init() tea.Cmd {
+	return nil
+}
+
+// newRoomChoices is the number of choices for opening a room, one per mode
+// and difficulty.
+const newRoomChoices = 2 * (int(Hard) + 1)
+
+// newRoomChoice returns the mode and difficulty of a choice for opening a
+// room.
+func newRoomChoice(i int) (roomMode, Difficulty) {
+	return roomMode(i / (int(Hard) + 1)), Difficulty(i % (int(Hard) + 1))
+}
+
+// choices is the number of lines of the room list.
+func (m LobbyModel) choices() int {
+	return newRoomChoices + len(m.open) + 1
+}
+
+func (m LobbyModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		keys := m.session.KeyMap()
+		switch {
+		case key.Matches(msg, keys.ForceQuit):
+			return m, tea.Quit
+		case key.Matches(msg, keys.Help):
+			m.help.ShowAll = !m.help.ShowAll
+		case m.room != nil:
+			switch {
+			case key.Matches(msg, keys.Select):
+				if err := roomHub.Start(m.session); err != nil {
+					m.err = err.Error()
+				}
+			case key.Matches(msg, keys.Back):
+				roomHub.Leave(m.session)
+				m.room = nil
+				m.err = ""
+				m.open = roomHub.Open()
+			}
+		case key.Matches(msg, keys.Up):
+			m.cursor = max(0, m.cursor-1)
+		case key.Matches(msg, keys.Down):
+			m.cursor = min(m.choices()-1, m.cursor+1)
+		case key.Matches(msg, keys.Select):
+			return m.choose()
+		case key.Matches(msg, keys.Back):
+			return NewMenuModel(m.width, m.height, m.session), nil
+		}
+
+	case roomsChangedMsg:
+		m.open = roomHub.Open()
+		m.cursor = min(m.choices()-1, m.cursor)
+
+	case roomChangedMsg:
+		if m.room == nil || msg.status.code != m.room.code {
+			return m, nil
+		}
+		if msg.status.state == roomRunning {
+			var model *GameModel
+			if msg.status.mode == coopMode {
+				model = NewCoopGameModel(m.width, m.height, m.session, msg.status)
+			} else {
+				model = NewRaceGameModel(m.width, m.height, m.session, msg.status)
+			}
+			return model, model.Init()
+		}
+		m.room = &msg.status
+
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.help.Width = msg.Width
+	}
+	return m, nil
+}
+
+// choose opens a room, joins one or goes back, depending on the choice under
+// the cursor.
+func (m LobbyModel) choose() (tea.Model, tea.Cmd) {
+	m.err = ""
+	switch i := m.cursor - newRoomChoices; {
+	case i < 0:
+		mode, difficulty := newRoomChoice(m.cursor)
+		status := roomHub.Create(m.session, mode, difficulty)
+		m.room = &status
+	case i < len(m.open):
+		status, err := roomHub.Join(m.session, m.open[i].code)
+		if err != nil {
+			m.err = err.Error()
+			return m, nil
+		}
+		m.room = &status
+	default:
+		return NewMenuModel(m.width, m.height, m.session), nil
+	}
+	return m, nil
+}
+
+func (m LobbyModel) View() string {
+	return lipgloss.Place(m.width, m.height,
+		lipgloss.Center, lipgloss.Center,
+		lipgloss.JoinVertical(lipgloss.Center, m.renderBox(), "", m.help.View(m.helpKeys())))
+}
+
+func (m LobbyModel) helpKeys() help.KeyMap {
+	k := m.session.KeyMap()
+	if m.room != nil {
+		keys := bindingHelp{relabel(k.Back, "leave room"), k.Help}
+		if m.room.host == m.session {
+			keys = append(bindingHelp{relabel(k.Select, "start game")}, keys...)
+		}
+		return keys
+	}
+	return bindingHelp{k.Up, k.Down, k.Select, k.Back, k.Help}
+}
+
+func (m LobbyModel) renderBox() string {
+	theme := m.session.Theme()
+	textStyle := theme.boxTextStyle()
+	cursorStyle := textStyle.Foreground(theme.BoxCursor).Bold(true)
+
+	var s strings.Builder
+	s.WriteString(textStyle.Bold(true).Render("MULTIPLAYER") + "\n\n")
+	if m.room != nil {
+		s.WriteString(textStyle.Render(fmt.Sprintf("Room %s - %s %s", m.room.code, m.room.difficulty, m.room.mode)) + "\n\n")
+		for _, r := range m.room.members {
+			name := r.name
+			if r.session == m.room.host {
+				name += " (host)"
+			}
+			s.WriteString(textStyle.Render("  "+name) + "\n")
+		}
+		status := "Waiting for the host to start the game"
+		if m.room.host == m.session {
+			status = "Start the game when everyone is here"
+		}
+		s.WriteString("\n" + textStyle.Render(status) + "\n")
+	} else {
+		s.WriteString(textStyle.Render("Open a room or join one:") + "\n")
+		var choices []string
+		for i := 0; i < newRoomChoices; i++ {
+			mode, difficulty := newRoomChoice(i)
+			choices = append(choices, fmt.Sprintf("New %s %s", difficulty, mode))
+		}
+		for _, room := range m.open {
+			choices = append(choices, fmt.Sprintf("Join %s - %s %s - %s (%d waiting)",
+				room.code, room.difficulty, room.mode, room.hostName(), len(room.members)))
+		}
+		choices = append(choices, backChoice)
+		for i, choice := range choices {
+			cursor := " "
+			choiceStyle := textStyle
+			if m.cursor == i {
+				cursor = cursorStyle.Render(">")
+				choiceStyle = textStyle.Foreground(theme.BoxSelected).Bold(true)
+			}
+			s.WriteString(fmt.Sprintf("%s%s\n", cursor, choiceStyle.Render(choice)))
+		}
+	}
+	if m.err != "" {
+		s.WriteString("\n" + textStyle.Foreground(theme.Failure).Render(m.err) + "\n")
+	}
+	return theme.boxStyle().Padding(2, 9).Render(s.String())
+}
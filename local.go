@@ -0,0 +1,95 @@
+package main
+
+import (
+	"crypto/rand"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/term"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// localKeyFiles are the private keys looked for in ~/.ssh, in order, when
+// the player's ssh-agent has no key to give them the identity they have when
+// playing over SSH.
+var localKeyFiles = []string{"id_ed25519", "id_ecdsa", "id_rsa"}
+
+// runLocal plays in the current terminal without the SSH server. The color
+// profile and window size are detected from the terminal itself.
+func runLocal() error {
+	width, height, err := term.GetSize(os.Stdout.Fd())
+	if err != nil {
+		width, height = 0, 0
+	}
+
+	session := NewLocalSession()
+	p := tea.NewProgram(NewMenuModel(width, height, session), tea.WithAltScreen(), tea.WithMouseCellMotion(), tea.WithFilter(saveOnQuit))
+	session.program = p
+	defer programs.add(p)()
+	_, err = p.Run()
+	return err
+}
+
+// NewLocalSession identifies the local player by an SSH key they can sign
+// with, so that scores and saves are shared with their SSH logins. Players
+// without one get a local identity, which can't post scores.
+func NewLocalSession() *Session {
+	username := "player"
+	if u, err := user.Current(); err == nil {
+		username = u.Username
+	}
+	session := &Session{PlayerID: "local:" + username, Username: username}
+	if fingerprint, ok := localKeyFingerprint(); ok {
+		session.KeyFingerprint = fingerprint
+		session.PlayerID = fingerprint
+		session.Name, _ = players.NameFor(fingerprint)
+	}
+	session.Settings = playerSettings.For(session.PlayerID)
+	return session
+}
+
+// localKeyFingerprint returns the fingerprint of a key the player proves
+// they hold by signing with it, through their ssh-agent or else with an
+// unencrypted private key in ~/.ssh. A public key isn't enough, since
+// anyone can copy one and play as its owner.
+func localKeyFingerprint() (string, bool) {
+	var signers []gossh.Signer
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		if conn, err := net.Dial("unix", sock); err == nil {
+			defer conn.Close()
+			if s, err := agent.NewClient(conn).Signers(); err == nil {
+				signers = append(signers, s...)
+			}
+		}
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		for _, name := range localKeyFiles {
+			data, err := os.ReadFile(filepath.Join(home, ".ssh", name))
+			if err != nil {
+				continue
+			}
+			// Keys protected by a passphrase fail to parse and are skipped.
+			signer, err := gossh.ParsePrivateKey(data)
+			if err != nil {
+				continue
+			}
+			signers = append(signers, signer)
+		}
+	}
+
+	challenge := make([]byte, 32)
+	if _, err := rand.Read(challenge); err != nil {
+		return "", false
+	}
+	for _, signer := range signers {
+		sig, err := signer.Sign(rand.Reader, challenge)
+		if err == nil && signer.PublicKey().Verify(challenge, sig) == nil {
+			return gossh.FingerprintSHA256(signer.PublicKey()), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// newTestKey writes a new key pair to ~/.ssh under name, returning the
+// private key and the public key's fingerprint.
+func newTestKey(t *testing.T, home, name string) (ed25519.PrivateKey, string) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, err := gossh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := gossh.MarshalPrivateKey(priv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(home, ".ssh")
+	os.MkdirAll(dir, 0o700)
+	os.WriteFile(filepath.Join(dir, name), pem.EncodeToMemory(block), 0o600)
+	os.WriteFile(filepath.Join(dir, name+".pub"), gossh.MarshalAuthorizedKey(pk), 0o644)
+	return priv, gossh.FingerprintSHA256(pk)
+}
+
+func TestLocalKeyNeedsPrivateKey(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("SSH_AUTH_SOCK", "")
+
+	// Someone else's public key, copied in.
+	newTestKey(t, home, "id_ed25519")
+	os.Remove(filepath.Join(home, ".ssh", "id_ed25519"))
+	if fingerprint, ok := localKeyFingerprint(); ok {
+		t.Fatalf("a public key alone identified the player as %s", fingerprint)
+	}
+
+	_, want := newTestKey(t, home, "id_rsa")
+	if fingerprint, ok := localKeyFingerprint(); !ok || fingerprint != want {
+		t.Errorf("localKeyFingerprint = %s, %v; want the private key's %s", fingerprint, ok, want)
+	}
+}
+
+func TestLocalKeyFromAgent(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	newTestKey(t, home, "id_ed25519")
+
+	// Unix socket paths are short, so the agent's can't go in TempDir.
+	dir, err := os.MkdirTemp("", "agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	sock := filepath.Join(dir, "sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	keyring := agent.NewKeyring()
+	priv, want := newTestKey(t, t.TempDir(), "agent")
+	keyring.Add(agent.AddedKey{PrivateKey: priv})
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go agent.ServeAgent(keyring, conn)
+		}
+	}()
+	t.Setenv("SSH_AUTH_SOCK", sock)
+
+	// The agent's key is the one ssh would offer first.
+	if fingerprint, ok := localKeyFingerprint(); !ok || fingerprint != want {
+		t.Errorf("localKeyFingerprint = %s, %v; want the agent's %s", fingerprint, ok, want)
+	}
+}
@@ -3,10 +3,11 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
 	"io"
-	"net"
 	"os"
 	"os/signal"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -19,44 +20,124 @@ import (
 	bm "github.com/charmbracelet/wish/bubbletea"
 	"github.com/charmbracelet/wish/logging"
 	"github.com/muesli/termenv"
+	gossh "golang.org/x/crypto/ssh"
 )
 
-const (
-	host = "localhost"
-	port = "23234"
-)
+func main() {
+	// "sudoku-tui local" plays in the current terminal instead of starting
+	// the SSH server.
+	args := os.Args[1:]
+	local := len(args) > 0 && args[0] == "local"
+	if local {
+		args = args[1:]
+	}
 
-const (
-	reset     = "\033[0m"
-	underline = "\033[4m"
-	bold      = "\033[1m"
-	red       = "\033[31m"
-	green     = "\033[32m"
-	yellow    = "\033[33m"
-	blue      = "\033[34m"
-	magenta   = "\033[35m"
-	cyan      = "\033[36m"
-	white     = "\033[37m"
-	boldWhite = "\033[1;37m"
-)
+	var err error
+	config, err = LoadConfig(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatal("invalid configuration", "error", err)
+	}
+	if err := os.MkdirAll(config.DataDir, 0755); err != nil {
+		log.Fatal("could not create data dir", "error", err)
+	}
+	lock, err := lockDataDir(config.DataDir)
+	if err != nil {
+		log.Fatal("could not lock data dir", "error", err)
+	}
+	defer lock.Close()
 
-func main() {
+	players, err = LoadPlayerRegistryFromFile(config.dataPath(playersFileName))
+	if err != nil {
+		log.Fatal("could not load players", "error", err)
+	}
+	themes, err = LoadThemesFromFile(config.themesPath())
+	if err != nil {
+		log.Fatal("could not load themes", "error", err)
+	}
+	keyMaps, err = LoadKeyMapsFromFile(config.keyMapsPath())
+	if err != nil {
+		log.Fatal("could not load key maps", "error", err)
+	}
+	playerSettings, err = LoadSettingsRegistryFromFile(config.dataPath(settingsFileName))
+	if err != nil {
+		log.Fatal("could not load settings", "error", err)
+	}
+	store, err := OpenLeaderboardStore(config.LeaderboardStore, config.dataPath(leaderboardName))
+	if err != nil {
+		log.Fatal("could not open leaderboard", "error", err)
+	}
+	leaderboardService, err = NewLeaderboardService(store)
+	if err != nil {
+		log.Fatal("could not load leaderboard", "error", err)
+	}
+	defer leaderboardService.Close()
+
+	dailyStore, err := OpenLeaderboardStore(config.LeaderboardStore, config.dataPath(dailyLeaderboardName))
+	if err != nil {
+		log.Fatal("could not open daily leaderboard", "error", err)
+	}
+	dailyLeaderboardService, err = NewLeaderboardService(dailyStore)
+	if err != nil {
+		log.Fatal("could not load daily leaderboard", "error", err)
+	}
+	defer dailyLeaderboardService.Close()
+	dailyAttempts, err = LoadDailyAttemptsFromFile(config.dataPath(dailyAttemptsName))
+	if err != nil {
+		log.Fatal("could not load daily attempts", "error", err)
+	}
+	dailySecret = config.DailySecret
+	if dailySecret == "" {
+		dailySecret, err = LoadDailySecretFromFile(config.dataPath(dailySecretName))
+		if err != nil {
+			log.Fatal("could not load daily secret", "error", err)
+		}
+	}
+
+	raceStore, err := OpenLeaderboardStore(config.LeaderboardStore, config.dataPath(raceLeaderboardName))
+	if err != nil {
+		log.Fatal("could not open race leaderboard", "error", err)
+	}
+	raceLeaderboardService, err = NewLeaderboardService(raceStore)
+	if err != nil {
+		log.Fatal("could not load race leaderboard", "error", err)
+	}
+	defer raceLeaderboardService.Close()
+
+	if local {
+		if err := runLocal(); err != nil {
+			log.Error("could not run game", "error", err)
+		}
+		return
+	}
+	runServer()
+}
+
+func runServer() {
 	s, err := wish.NewServer(
-		wish.WithAddress(net.JoinHostPort(host, port)),
-		wish.WithHostKeyPath(".ssh/term_info_ed25519"),
+		wish.WithAddress(config.Address()),
+		wish.WithHostKeyPath(config.HostKeyPath),
+		wish.WithIdleTimeout(config.IdleTimeout),
+		// Any key is accepted: keys identify players rather than restrict
+		// access. Players without a key can still play, but not post scores.
+		wish.WithPublicKeyAuth(func(ssh.Context, ssh.PublicKey) bool { return true }),
+		wish.WithKeyboardInteractiveAuth(func(ssh.Context, gossh.KeyboardInteractiveChallenge) bool { return true }),
 		wish.WithMiddleware(
-			bm.Middleware(teaHandler),
+			bm.MiddlewareWithProgramHandler(programHandler, termenv.Ascii),
 			activeterm.Middleware(),
+			maxSessionsMiddleware(config.MaxSessions),
 			logging.Middleware(),
 		),
 	)
 	if err != nil {
-		log.Error("could not start server", "error", err)
+		log.Fatal("could not start server", "error", err)
 	}
 
 	done := make(chan os.Signal, 1)
 	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
-	log.Info("Starting SSH server", "host", host, "port", port)
+	log.Info("Starting SSH server", "address", config.Address())
 
 	go func() {
 		if err = s.ListenAndServe(); err != nil && !errors.Is(err, ssh.ErrServerClosed) {
@@ -74,6 +155,23 @@ func main() {
 	}
 }
 
+// maxSessionsMiddleware turns away new sessions once limit sessions are
+// active. A limit of 0 means unlimited.
+func maxSessionsMiddleware(limit int) wish.Middleware {
+	var active int64
+	return func(next ssh.Handler) ssh.Handler {
+		return func(s ssh.Session) {
+			n := atomic.AddInt64(&active, 1)
+			defer atomic.AddInt64(&active, -1)
+			if limit > 0 && n > int64(limit) {
+				wish.Fatalln(s, "The server is full, please try again later.")
+				return
+			}
+			next(s)
+		}
+	}
+}
+
 type forceColorWriter struct {
 	w io.Writer
 }
@@ -82,13 +180,36 @@ func (fcw forceColorWriter) Write(p []byte) (n int, err error) {
 	return fcw.w.Write(p)
 }
 
-func teaHandler(s ssh.Session) (tea.Model, []tea.ProgramOption) {
+// programHandler starts a program for the session and registers it so that
+// it receives server-wide broadcasts until the session ends. A player who
+// disconnects also leaves their multiplayer room and stops being watched.
+func programHandler(s ssh.Session) *tea.Program {
+	session := NewSSHSession(s)
+	model, opts := teaHandler(s, session)
+	p := tea.NewProgram(model, append(opts, bm.MakeOptions(s)...)...)
+	session.program = p
+	remove := programs.add(p)
+	go func() {
+		<-s.Context().Done()
+		roomHub.Leave(session)
+		liveGames.Leave(session)
+		remove()
+	}()
+	return p
+}
+
+func teaHandler(s ssh.Session, session *Session) (tea.Model, []tea.ProgramOption) {
 	pty, _, _ := s.Pty()
 
+	// The styles share lipgloss' default renderer, whose color profile was
+	// detected from the server's own stdout, so force a profile that SSH
+	// clients' terminals commonly support.
 	lipgloss.SetColorProfile(termenv.ANSI256)
 
-	return NewMenuModel(pty.Window.Width, pty.Window.Height), []tea.ProgramOption{
+	return NewMenuModel(pty.Window.Width, pty.Window.Height, session), []tea.ProgramOption{
 		tea.WithAltScreen(),
+		tea.WithMouseCellMotion(),
 		tea.WithOutput(forceColorWriter{s}),
+		tea.WithFilter(saveOnQuit),
 	}
 }
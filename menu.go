@@ -2,6 +2,10 @@ package main
 
 import (
 	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -18,19 +22,46 @@ func (d Difficulty) String() string {
 	return [...]string{"Easy", "Medium", "Hard"}[d]
 }
 
+const (
+	continueChoice = "Continue game"
+	dailyChoice    = "Daily puzzle"
+	seedChoice     = "Play a seed code"
+	lobbyChoice    = "Play with others"
+	watchChoice    = "Watch a game"
+	importChoice   = "Enter puzzle"
+	editorChoice   = "Create puzzle"
+	settingsChoice = "Settings"
+	backChoice     = "Back"
+	quitChoice     = "Quit"
+)
+
 type MenuModel struct {
 	choices  []string
 	cursor   int
 	selected int
 	width    int
 	height   int
+	session  *Session
+	// daily is set while choosing the difficulty of today's daily puzzle.
+	daily bool
+	// enteringSeed is set while typing a seed code into seedInput.
+	enteringSeed bool
+	seedInput    string
+	seedErr      string
+	help         help.Model
 }
 
-func NewMenuModel(width, height int) *MenuModel {
+func NewMenuModel(width, height int, session *Session) *MenuModel {
+	choices := []string{"Easy", "Medium", "Hard", dailyChoice, lobbyChoice, watchChoice, seedChoice, importChoice, editorChoice, settingsChoice, quitChoice}
+	if hasSavedGame(session.PlayerID) {
+		choices = append([]string{continueChoice}, choices...)
+	}
 	return &MenuModel{
-		choices: []string{"Easy", "Medium", "Hard", "Quit"},
+		choices: choices,
 		width:   width,
 		height:  height,
+		session: session,
+		help:    newHelp(session.Theme(), width),
 	}
 }
 
@@ -41,77 +72,187 @@ func (m MenuModel) Init() tea.Cmd {
 func (m MenuModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q":
+		if m.enteringSeed {
+			return m.updateSeedEntry(msg)
+		}
+		keys := m.session.KeyMap()
+		switch {
+		case key.Matches(msg, keys.ForceQuit, keys.Quit):
 			return m, tea.Quit
-		case "up":
+		case key.Matches(msg, keys.Up):
 			if m.cursor > 0 {
 				m.cursor--
 			}
-		case "down":
+		case key.Matches(msg, keys.Down):
 			if m.cursor < len(m.choices)-1 {
 				m.cursor++
 			}
-		case "enter":
-			m.selected = m.cursor
-			if m.selected == len(m.choices)-1 {
-				return m, tea.Quit
+		case key.Matches(msg, keys.Select):
+			return m.choose()
+		case key.Matches(msg, keys.Help):
+			m.help.ShowAll = !m.help.ShowAll
+		}
+	case tea.MouseMsg:
+		if m.enteringSeed {
+			return m, nil
+		}
+		switch {
+		case msg.Button == tea.MouseButtonWheelUp && m.cursor > 0:
+			m.cursor--
+		case msg.Button == tea.MouseButtonWheelDown && m.cursor < len(m.choices)-1:
+			m.cursor++
+		case isLeftClick(msg):
+			if i, ok := m.choiceAt(msg.X, msg.Y); ok {
+				m.cursor = i
+				return m.choose()
 			}
-			return NewGameModel(m.width, m.height, Difficulty(m.selected)), nil
 		}
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
+		m.help.Width = msg.Width
+	}
+	return m, nil
+}
+
+// choose acts on the choice under the cursor.
+func (m MenuModel) choose() (tea.Model, tea.Cmd) {
+	m.selected = m.cursor
+	switch m.choices[m.selected] {
+	case quitChoice:
+		return m, tea.Quit
+	case backChoice:
+		return NewMenuModel(m.width, m.height, m.session), nil
+	case settingsChoice:
+		return NewSettingsModel(m.width, m.height, m.session), nil
+	case watchChoice:
+		model := NewSpectatorModel(m.width, m.height, m.session)
+		return model, model.Init()
+	case lobbyChoice:
+		return NewLobbyModel(m.width, m.height, m.session), nil
+	case editorChoice:
+		return NewEditorModel(m.width, m.height, m.session), nil
+	case importChoice:
+		model := NewImportModel(m.width, m.height, m.session)
+		return model, model.Init()
+	case seedChoice:
+		m.enteringSeed = true
+		m.seedInput = ""
+		m.seedErr = ""
+		return m, nil
+	case dailyChoice:
+		m.daily = true
+		m.choices = []string{"Easy", "Medium", "Hard", backChoice}
+		m.cursor = 0
+		return m, nil
+	case continueChoice:
+		game, err := LoadSavedGameFromFile(savedGamePath(m.session.PlayerID))
+		if err == nil && game != nil {
+			model := NewGameModelFromSave(m.width, m.height, m.session, game)
+			return model, model.Init()
+		}
+		m.choices = m.choices[1:]
+		m.cursor = 0
+		return m, nil
+	}
+	for d := Easy; d <= Hard; d++ {
+		if m.choices[m.selected] == d.String() {
+			var model *GameModel
+			if m.daily {
+				model = NewDailyGameModel(m.width, m.height, d, m.session)
+			} else {
+				model = NewGameModel(m.width, m.height, d, m.session)
+			}
+			return model, model.Init()
+		}
+	}
+	return m, nil
+}
+
+func (m MenuModel) updateSeedEntry(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	keys := m.session.KeyMap()
+	switch {
+	case key.Matches(msg, keys.Confirm):
+		model, err := NewSeededGameModel(m.width, m.height, m.seedInput, m.session)
+		if err != nil {
+			m.seedErr = err.Error()
+			return m, nil
+		}
+		return model, model.Init()
+	case key.Matches(msg, keys.Cancel):
+		m.enteringSeed = false
+	case key.Matches(msg, keys.ForceQuit):
+		return m, tea.Quit
+	case msg.Type == tea.KeyBackspace:
+		if len(m.seedInput) > 0 {
+			m.seedInput = m.seedInput[:len(m.seedInput)-1]
+		}
+	case msg.Type == tea.KeyRunes:
+		if len(m.seedInput) < 16 {
+			m.seedInput += strings.ToUpper(string(msg.Runes))
+		}
 	}
 	return m, nil
 }
 
 func (m MenuModel) View() string {
-	menuBgColor := lipgloss.Color("11")
-	var cursorStyle = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("9")).
-		Background(menuBgColor).
-		Bold(true)
-	s := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("0")).
-		Background(menuBgColor).
-		Bold(true).
-		Render("SUDOKU BUILT BY AMAN") + "\n\n"
-	s += lipgloss.NewStyle().
-		Foreground(lipgloss.Color("0")).
-		Background(menuBgColor).
-		Render("Select difficulty:") + "\n"
-	for i, choice := range m.choices {
+	return lipgloss.Place(m.width, m.height,
+		lipgloss.Center, lipgloss.Center,
+		lipgloss.JoinVertical(lipgloss.Center, m.renderBox(), "", m.help.View(m.helpKeys())))
+}
+
+func (m MenuModel) helpKeys() help.KeyMap {
+	k := m.session.KeyMap()
+	if m.enteringSeed {
+		return bindingHelp{relabel(k.Confirm, "play"), k.Cancel}
+	}
+	return bindingHelp{k.Up, k.Down, k.Select, k.Quit, k.Help}
+}
+
+// menuChoicesTop is the line of the menu box the choices start on, below
+// its border, padding, header and title.
+const menuChoicesTop = 1 + 2 + 3
+
+// choiceAt maps a screen position to the choice drawn there, if any.
+func (m MenuModel) choiceAt(x, y int) (int, bool) {
+	box := m.renderBox()
+	view := lipgloss.JoinVertical(lipgloss.Center, box, "", m.help.View(m.helpKeys()))
+	width := lipgloss.Width(box)
+	left := placeOffset(m.width, lipgloss.Width(view)) + joinOffset(lipgloss.Width(view), width)
+	top := placeOffset(m.height, lipgloss.Height(view))
+	i := y - top - menuChoicesTop
+	if x < left || x >= left+width || i < 0 || i >= len(m.choices) {
+		return 0, false
+	}
+	return i, true
+}
+
+func (m MenuModel) renderBox() string {
+	theme := m.session.Theme()
+	textStyle := theme.boxTextStyle()
+	cursorStyle := textStyle.Foreground(theme.BoxCursor).Bold(true)
+	s := textStyle.Bold(true).Render("SUDOKU BUILT BY AMAN") + "\n\n"
+	title := "Select a game:"
+	if m.daily {
+		title = fmt.Sprintf("Daily puzzle for %s:", today())
+	} else if m.enteringSeed {
+		title = "Enter a seed code (esc to cancel):"
+	}
+	s += textStyle.Render(title) + "\n"
+	choices := m.choices
+	if m.enteringSeed {
+		s += textStyle.Render(fmt.Sprintf("> %s\n\n%s", m.seedInput, m.seedErr)) + "\n"
+		choices = nil
+	}
+	for i, choice := range choices {
 		cursor := " "
+		choiceStyle := textStyle
 		if m.cursor == i {
 			cursor = cursorStyle.Render(">")
-		}
-		choiceStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("0")).
-			Background(menuBgColor)
-		if m.cursor == i {
-			choiceStyle = choiceStyle.
-				Foreground(lipgloss.Color("201")).
-				Bold(true).
-				Background(menuBgColor)
+			choiceStyle = textStyle.Foreground(theme.BoxSelected).Bold(true)
 		}
 		s += fmt.Sprintf("%s%s\n", cursor, choiceStyle.Render(choice))
 	}
 
-	boxStyle := lipgloss.NewStyle().
-		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("11")).
-		BorderBackground(lipgloss.Color("11")).
-		Background(lipgloss.Color("11")).
-		Padding(2, 9).
-		BorderTop(true).
-		BorderLeft(true).
-		BorderRight(true).
-		BorderBottom(true)
-
-	menu := boxStyle.Render(s)
-
-	return lipgloss.Place(m.width, m.height,
-		lipgloss.Center, lipgloss.Center,
-		menu)
+	return theme.boxStyle().Padding(2, 9).Render(s)
 }
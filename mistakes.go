@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// mistakeLimits are the choices for how many mistakes end a game; 0 means
+// mistakes are only counted.
+var mistakeLimits = []int{0, 1, 2, 3, 5}
+
+func formatMistakeLimit(limit int) string {
+	if limit == 0 {
+		return "Off"
+	}
+	return fmt.Sprint(limit)
+}
+
+// countMistake is called after the player changes a cell to a new digit.
+// A digit that contradicts the solution counts as a mistake even if it is
+// later undone, and reaching the game's limit loses it.
+func (m *GameModel) countMistake(row, col int) {
+	if m.initialBoard[row][col] != 0 || m.board[row][col] == 0 || m.board[row][col] == m.solution[row][col] {
+		return
+	}
+	m.mistakes++
+	if m.mistakeLimit > 0 && m.mistakes >= m.mistakeLimit {
+		m.state = Lost
+		m.pauseClock()
+		m.discardSave()
+		m.leaveRoom()
+	}
+}
+
+func (m GameModel) mistakesText() string {
+	if m.mistakeLimit == 0 {
+		return fmt.Sprint(m.mistakes)
+	}
+	return fmt.Sprintf("%d/%d", m.mistakes, m.mistakeLimit)
+}
+
+// renderLost reveals the solution, flagging the cells the player got wrong.
+func (m GameModel) renderLost() string {
+	reveal := m
+	reveal.board = m.solution
+	reveal.notes = [sudokuLen][sudokuLen]candidates{}
+	reveal.notesMode = false
+	reveal.hint = nil
+	reveal.assist = AssistOff
+	reveal.cursor = coordinate{-1, -1}
+	reveal.remainingErrCoordinates = make(map[coordinate]bool)
+	for i := 0; i < sudokuLen; i++ {
+		for j := 0; j < sudokuLen; j++ {
+			if m.board[i][j] != 0 && m.board[i][j] != m.solution[i][j] {
+				reveal.remainingErrCoordinates[coordinate{i, j}] = true
+			}
+		}
+	}
+
+	var s strings.Builder
+	s.WriteString(m.theme.failureStyle().Bold(true).Render(
+		fmt.Sprintf("Game over - %d mistakes", m.mistakes)) + "\n\n")
+	s.WriteString("Here is the solution. Your wrong digits are highlighted.\n\n")
+	s.WriteString(m.help.View(m.helpKeys()))
+	return lipgloss.JoinVertical(lipgloss.Center, reveal.renderBoard(), s.String())
+}
@@ -0,0 +1,57 @@
+package main
+
+import "testing"
+
+// enter types digit at (row, col) the way the number keys do.
+func enter(m *GameModel, row, col, digit int) {
+	m.cursor = coordinate{row, col}
+	m.enterDigit(digit)
+}
+
+func TestMistakeLimitLosesTheGame(t *testing.T) {
+	m := newTestGame(t)
+	m.mistakeLimit = 2
+	m.resumeClock()
+
+	enter(m, 0, 2, 2) // the solution has a 4
+	m.undo()
+	enter(m, 0, 2, 4)
+	enter(m, 0, 0, 9) // a given, so nothing is entered
+	if m.mistakes != 1 || m.state != Playing {
+		t.Fatalf("mistakes %d, state %v; want the undone 2 alone to count", m.mistakes, m.state)
+	}
+
+	enter(m, 0, 3, 1) // the solution has a 6
+	if m.mistakes != 2 || m.state != Lost {
+		t.Fatalf("mistakes %d, state %v; want the second mistake to lose", m.mistakes, m.state)
+	}
+	if m.clockRunning {
+		t.Error("the clock kept running after the game was lost")
+	}
+}
+
+func TestMistakesWithoutLimit(t *testing.T) {
+	m := newTestGame(t)
+	for _, digit := range []int{1, 2, 3, 5, 7} {
+		enter(m, 0, 2, digit)
+	}
+	if m.mistakes != 5 || m.state != Playing || m.mistakesText() != "5" {
+		t.Errorf("mistakes %q, state %v; want five counted and still playing", m.mistakesText(), m.state)
+	}
+}
+
+// Pressing the same wrong digit again, or on a cell that can't change,
+// isn't another mistake.
+func TestRepeatedDigitIsOneMistake(t *testing.T) {
+	m := newTestGame(t)
+	m.mistakeLimit = 2
+	enter(m, 0, 2, 2)
+	enter(m, 0, 2, 2)
+	if m.mistakes != 1 || m.state != Playing {
+		t.Fatalf("mistakes %d, state %v; want one mistake", m.mistakes, m.state)
+	}
+	enter(m, 0, 2, 1)
+	if m.state != Lost {
+		t.Error("a different wrong digit wasn't counted")
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// padButton is a button of the on-screen number pad: a digit, or one of
+// padNotes and padClear.
+type padButton int
+
+const (
+	padClear padButton = 0
+	padNotes padButton = -1
+)
+
+var numberPad = [][]padButton{
+	{1, 2, 3},
+	{4, 5, 6},
+	{7, 8, 9},
+	{padNotes, padClear},
+}
+
+const (
+	// padButtonWidth is a button's label plus its padding; buttons are
+	// separated by a column and rows by a line.
+	padButtonWidth = 3
+	// padMargin separates the number pad from the board.
+	padMargin = 3
+)
+
+// gameLayout records where renderGame drew the board and number pad, so
+// that mouse clicks can be mapped back onto them.
+type gameLayout struct {
+	board, pad coordinate
+	tall       bool
+}
+
+// placeOffset is where lipgloss.Place puts size n centered in total.
+func placeOffset(total, n int) int {
+	return max(0, total-n) / 2
+}
+
+// joinOffset is where lipgloss.JoinVertical puts a line of width n centered
+// in total. It rounds the other way from lipgloss.Place.
+func joinOffset(total, n int) int {
+	return (total - n + 1) / 2
+}
+
+// boardCellAt maps a position relative to the board's top-left corner to
+// the cell drawn there, if any. tall is whether the board was drawn with
+// room for notes.
+func boardCellAt(x, y int, tall bool) (coordinate, bool) {
+	cellWidth, cellHeight := 3, 1
+	if tall {
+		cellWidth, cellHeight = 5, 3
+	}
+	// Each box is three cells followed by a separator: a one-column border
+	// with a margin on either side, or a one-line border.
+	boxWidth, boxHeight := 3*cellWidth+3, 3*cellHeight+1
+	if x < 0 || y < 0 || x%boxWidth >= 3*cellWidth || y%boxHeight >= 3*cellHeight {
+		return coordinate{}, false
+	}
+	c := coordinate{
+		row: y/boxHeight*3 + y%boxHeight/cellHeight,
+		col: x/boxWidth*3 + x%boxWidth/cellWidth,
+	}
+	if c.row >= sudokuLen || c.col >= sudokuLen {
+		return coordinate{}, false
+	}
+	return c, true
+}
+
+// padButtonAt maps a position relative to the number pad's top-left corner
+// to the button drawn there, if any.
+func padButtonAt(x, y int) (padButton, bool) {
+	if x < 0 || y < 0 || x%(padButtonWidth+1) == padButtonWidth || y%2 == 1 {
+		return 0, false
+	}
+	row, col := y/2, x/(padButtonWidth+1)
+	if row >= len(numberPad) || col >= len(numberPad[row]) {
+		return 0, false
+	}
+	return numberPad[row][col], true
+}
+
+func (m GameModel) renderNumberPad() string {
+	var placed [sudokuLen + 1]int
+	for i := 0; i < sudokuLen; i++ {
+		for j := 0; j < sudokuLen; j++ {
+			placed[m.board[i][j]]++
+		}
+	}
+
+	var rows []string
+	for _, buttons := range numberPad {
+		var row []string
+		for _, b := range buttons {
+			var label string
+			style := m.theme.cellStyle(cellFlags{modifiable: true})
+			switch b {
+			case padNotes:
+				label = "n"
+				if m.notesMode {
+					style = m.theme.cellStyle(cellFlags{modifiable: true, isCursor: true})
+				}
+			case padClear:
+				label = "⌫"
+			default:
+				label = string(rune('0' + b))
+				// Digits that are all placed are dimmed, like on a phone app.
+				if placed[b] >= sudokuLen {
+					style = m.theme.cellStyle(cellFlags{}).Foreground(m.theme.DoneText)
+				}
+			}
+			row = append(row, style.Render(label))
+		}
+		rows = append(rows, strings.Join(row, " "))
+	}
+	return strings.Join(rows, "\n\n")
+}
+
+// click moves the cursor to a clicked cell or presses a clicked number pad
+// button.
+func (m GameModel) click(x, y int) (tea.Model, tea.Cmd) {
+	_, layout := m.layoutGame()
+	if c, ok := boardCellAt(x-layout.board.col, y-layout.board.row, layout.tall); ok {
+		m.cursor = c
+		m.shareCursor()
+		return m, nil
+	}
+	b, ok := padButtonAt(x-layout.pad.col, y-layout.pad.row)
+	if !ok {
+		return m, nil
+	}
+	switch b {
+	case padNotes:
+		m.notesMode = !m.notesMode
+	case padClear:
+		m.clearCursor()
+	default:
+		m.enterDigit(int(b))
+	}
+	return m, nil
+}
+
+// isLeftClick reports whether msg is the press of the left mouse button.
+// Releases and motion are ignored, so a click acts once.
+func isLeftClick(msg tea.MouseMsg) bool {
+	return msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft
+}
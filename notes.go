@@ -0,0 +1,54 @@
+package main
+
+import "strings"
+
+// toggleNote adds or removes digit from the pencil marks of an empty,
+// editable cell.
+func (m *GameModel) toggleNote(row, col, digit int) {
+	if m.initialBoard[row][col] != 0 || m.board[row][col] != 0 {
+		return
+	}
+	m.notes[row][col] ^= 1 << digit
+}
+
+// removeNoteFromPeers drops digit from the notes of every cell sharing a
+// row, column or box with the given cell.
+func (m *GameModel) removeNoteFromPeers(row, col, digit int) {
+	for _, p := range peers[row][col] {
+		m.notes[p.row][p.col] &^= 1 << digit
+	}
+}
+
+// showsNotes reports whether the board should use the taller layout that
+// leaves room for a 3x3 grid of notes in each cell.
+func (m GameModel) showsNotes() bool {
+	if m.notesMode {
+		return true
+	}
+	for i := 0; i < sudokuLen; i++ {
+		for j := 0; j < sudokuLen; j++ {
+			if m.board[i][j] == 0 && m.notes[i][j] != 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// formatNotes lays out a cell's notes as a 3x3 grid with each digit in the
+// position it has on a phone keypad.
+func formatNotes(c candidates) string {
+	var lines []string
+	for r := 0; r < 3; r++ {
+		var line strings.Builder
+		for d := r*3 + 1; d <= r*3+3; d++ {
+			if c.has(d) {
+				line.WriteByte(byte('0' + d))
+			} else {
+				line.WriteByte(' ')
+			}
+		}
+		lines = append(lines, line.String())
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+const (
+	playersFileName  = "sudoku_players.json"
+	maxPlayerNameLen = 20
+)
+
+// PlayerRegistry binds display names to SSH public key fingerprints, so a
+// name on the leaderboard can only ever be posted from the key that chose it.
+type PlayerRegistry struct {
+	mu       sync.Mutex
+	filename string
+	Names    map[string]string `json:"names"`
+}
+
+var players = NewPlayerRegistry(playersFileName)
+
+func NewPlayerRegistry(filename string) *PlayerRegistry {
+	return &PlayerRegistry{
+		filename: filename,
+		Names:    map[string]string{},
+	}
+}
+
+func LoadPlayerRegistryFromFile(filename string) (*PlayerRegistry, error) {
+	registry := NewPlayerRegistry(filename)
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return registry, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, registry); err != nil {
+		return nil, err
+	}
+	if registry.Names == nil {
+		registry.Names = map[string]string{}
+	}
+	return registry, nil
+}
+
+// NameFor returns the display name bound to a key fingerprint.
+func (r *PlayerRegistry) NameFor(fingerprint string) (string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	name, ok := r.Names[fingerprint]
+	return name, ok
+}
+
+// Register binds name to fingerprint. Names are unique regardless of case,
+// and a key keeps the first name it registers.
+func (r *PlayerRegistry) Register(fingerprint, name string) error {
+	name = strings.TrimSpace(name)
+	if err := validatePlayerName(name); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.Names[fingerprint]; ok {
+		return fmt.Errorf("this key is already registered as %q", existing)
+	}
+	for _, taken := range r.Names {
+		if strings.EqualFold(taken, name) {
+			return fmt.Errorf("the name %q is already taken", name)
+		}
+	}
+	r.Names[fingerprint] = name
+	if err := r.saveToFile(); err != nil {
+		// The key mustn't keep a name that would be gone after a restart.
+		delete(r.Names, fingerprint)
+		return err
+	}
+	return nil
+}
+
+func (r *PlayerRegistry) saveToFile() error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(r.filename, data, 0644)
+}
+
+func validatePlayerName(name string) error {
+	if name == "" {
+		return errors.New("name can't be empty")
+	}
+	if len([]rune(name)) > maxPlayerNameLen {
+		return fmt.Errorf("name can't be longer than %d characters", maxPlayerNameLen)
+	}
+	for _, r := range name {
+		if !unicode.IsPrint(r) {
+			return errors.New("name can only contain printable characters")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// programRegistry tracks the running tea programs so that server-wide events
+// can be delivered to every session.
+type programRegistry struct {
+	mu       sync.Mutex
+	programs map[*tea.Program]struct{}
+}
+
+var programs = &programRegistry{programs: map[*tea.Program]struct{}{}}
+
+// add registers p and returns a function that unregisters it.
+func (r *programRegistry) add(p *tea.Program) func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.programs[p] = struct{}{}
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.programs, p)
+	}
+}
+
+// broadcast sends msg to every registered program. Sends happen in their
+// own goroutines since Send blocks until the program reads the message, and
+// the caller may itself be running inside one of the programs' Update.
+func (r *programRegistry) broadcast(msg tea.Msg) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for p := range r.programs {
+		go p.Send(msg)
+	}
+}
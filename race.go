@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const raceLeaderboardName = "sudoku_race_leaderboard"
+
+// raceLeaderboardService ranks the winning times of races. It's opened in
+// main.
+var raceLeaderboardService *LeaderboardService
+
+// Progress records how many cells session has filled in its race.
+func (h *RoomHub) Progress(session *Session, filled int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	r, m := h.running(session)
+	if r == nil || r.mode != raceMode || m.place > 0 || m.filled == filled {
+		return
+	}
+	m.filled = filled
+	h.notify(r)
+}
+
+// Finish records that session solved its race's puzzle and returns the
+// place it finished in, or 0 if it isn't in a running race.
+func (h *RoomHub) Finish(session *Session, elapsed time.Duration) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	r, m := h.running(session)
+	if r == nil || r.mode != raceMode {
+		return 0
+	}
+	if m.place == 0 {
+		r.finishers++
+		m.place = r.finishers
+		m.filled = r.cells
+		m.time = elapsed
+		h.notify(r)
+	}
+	return m.place
+}
+
+// NewRaceGameModel starts a racer's game once their room's race begins.
+func NewRaceGameModel(width, height int, session *Session, status roomStatus) *GameModel {
+	m := newGameModel(width, height, status.difficulty, status.puzzle, status.solution, session)
+	m.seed = status.seed
+	m.room = &status
+	m.leaderboard = raceLeaderboardService
+	m.menuOptions[1] = "Leave Race"
+	return m
+}
+
+// reportRaceProgress tells the other racers how many cells the player has
+// filled, without revealing the digits.
+func (m GameModel) reportRaceProgress() {
+	if m.inRoom(raceMode) {
+		roomHub.Progress(m.session, m.room.cells-m.cellsLeft)
+	}
+}
+
+// finishRace is called when the player solves a race's puzzle. Only the
+// winner's time goes on the race leaderboard.
+func (m *GameModel) finishRace() {
+	if !m.inRoom(raceMode) {
+		return
+	}
+	switch place := roomHub.Finish(m.session, m.elapsedTimeOnWin); place {
+	case 0:
+		m.unranked = "you left the race"
+	case 1:
+	default:
+		m.unranked = fmt.Sprintf("you finished %s in the race", ordinal(place))
+	}
+}
+
+// raceBarWidth is the width of the progress bars in the race panel.
+const raceBarWidth = 10
+
+// renderRacePanel shows every racer's progress next to the board.
+func (m GameModel) renderRacePanel() string {
+	var s strings.Builder
+	s.WriteString(m.theme.textStyle().Bold(true).Render("Race "+m.room.code) + "\n\n")
+	for _, r := range m.room.members {
+		marker := "  "
+		if r.session == m.session {
+			marker = m.theme.markerStyle().Render("> ")
+		}
+		var progress string
+		switch {
+		case r.place > 0:
+			progress = m.theme.successStyle().Render(fmt.Sprintf("%s %s", ordinal(r.place), formatDuration(r.time)))
+		case r.left:
+			progress = m.theme.failureStyle().Render("left")
+		default:
+			done := r.filled * raceBarWidth / max(1, m.room.cells)
+			progress = m.theme.textStyle().Render(fmt.Sprintf("%s%s %d/%d",
+				strings.Repeat("█", done), strings.Repeat("░", raceBarWidth-done), r.filled, m.room.cells))
+		}
+		s.WriteString(fmt.Sprintf("%s%s\n  %s\n", marker, m.theme.textStyle().Render(truncateString(r.name, 16)), progress))
+	}
+	return s.String()
+}
+
+// ordinal formats a finishing place, e.g. "2nd".
+func ordinal(n int) string {
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
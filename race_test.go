@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRaceFinishingPlaces(t *testing.T) {
+	h := NewRoomHub()
+	ann, bob, cat := &Session{Username: "ann"}, &Session{Username: "bob"}, &Session{Username: "cat"}
+	code := h.Create(ann, raceMode, Easy).code
+	h.Join(bob, code)
+	h.Join(cat, code)
+	h.Start(ann)
+
+	if place := h.Finish(bob, time.Minute); place != 1 {
+		t.Fatalf("the first to finish came %s", ordinal(place))
+	}
+	// Leaving mid-race keeps the racer on the board for the others.
+	h.Leave(cat)
+	if place := h.Finish(ann, 2*time.Minute); place != 2 {
+		t.Fatalf("the second to finish came %s", ordinal(place))
+	}
+	if place := h.Finish(bob, 3*time.Minute); place != 1 {
+		t.Errorf("finishing twice changed bob's place to %s", ordinal(place))
+	}
+	if h.Finish(cat, 4*time.Minute) != 0 {
+		t.Error("a racer who left could still finish")
+	}
+
+	status := h.rooms[code].status()
+	if len(status.members) != 3 || !status.members[2].left || status.members[1].time != time.Minute {
+		t.Errorf("the room's members are %+v", status.members)
+	}
+
+	// The room closes when the last racer leaves.
+	h.Leave(ann)
+	h.Leave(bob)
+	if len(h.rooms) != 0 || len(h.memberOf) != 0 {
+		t.Error("an empty room was kept")
+	}
+}
+
+func TestRaceConcurrentJoins(t *testing.T) {
+	h := NewRoomHub()
+	host := &Session{Username: "host"}
+	code := h.Create(host, raceMode, Easy).code
+
+	const players = 20
+	var wg sync.WaitGroup
+	for i := 0; i < players; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s := &Session{Username: fmt.Sprintf("player%d", i)}
+			if _, err := h.Join(s, code); err != nil {
+				t.Error(err)
+			}
+			h.Progress(s, i)
+		}()
+	}
+	wg.Wait()
+	if n := len(h.rooms[code].members); n != players+1 {
+		t.Errorf("the room has %d racers; want %d", n, players+1)
+	}
+}
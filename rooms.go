@@ -0,0 +1,384 @@
+package main
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// roomMode is what the players of a room play: a race, where each solves
+// their own copy of the puzzle, or a co-op game on one shared board.
+type roomMode int
+
+const (
+	raceMode roomMode = iota
+	coopMode
+)
+
+func (m roomMode) String() string {
+	return [...]string{"race", "co-op"}[m]
+}
+
+type roomState int
+
+const (
+	roomWaiting roomState = iota
+	roomRunning
+)
+
+// member is one player in a room. Players who leave a running game are
+// kept so that the others still see what they did.
+type member struct {
+	session *Session
+	name    string
+	left    bool
+
+	// Race progress. place is the order the member finished in, or 0 while
+	// they haven't.
+	filled int
+	place  int
+	time   time.Duration
+
+	// cursor is where the member is on a co-op board.
+	cursor coordinate
+}
+
+type room struct {
+	code       string
+	mode       roomMode
+	difficulty Difficulty
+	seed       int64
+	puzzle     [sudokuLen][sudokuLen]int
+	solution   [sudokuLen][sudokuLen]int
+	// cells is the number of cells to fill.
+	cells     int
+	host      *Session
+	members   []*member
+	state     roomState
+	finishers int
+	// version counts the changes sent to the members. Sends can arrive out
+	// of order, so programs ignore states older than one they've seen.
+	version int
+
+	// board is the shared board of a co-op game, and owners who last
+	// wrote each of its cells.
+	board  [sudokuLen][sudokuLen]int
+	owners [sudokuLen][sudokuLen]*Session
+	solved bool
+}
+
+// memberStatus and roomStatus are copies of a room's state that can be
+// handed to other programs without holding the hub's lock.
+type memberStatus struct {
+	session *Session
+	name    string
+	left    bool
+	filled  int
+	place   int
+	time    time.Duration
+	cursor  coordinate
+	// owned is the number of cells of a co-op board the member wrote.
+	owned int
+}
+
+type roomStatus struct {
+	code       string
+	mode       roomMode
+	difficulty Difficulty
+	seed       int64
+	puzzle     [sudokuLen][sudokuLen]int
+	solution   [sudokuLen][sudokuLen]int
+	cells      int
+	host       *Session
+	state      roomState
+	version    int
+	members    []memberStatus
+	board      [sudokuLen][sudokuLen]int
+	owners     [sudokuLen][sudokuLen]*Session
+	solved     bool
+}
+
+// roomChangedMsg is sent to the members of a room whenever anything about
+// it changes.
+type roomChangedMsg struct {
+	status roomStatus
+}
+
+// roomsChangedMsg is sent to every session when a room opens, fills up or
+// starts, so that lobbies redraw their list of rooms.
+type roomsChangedMsg struct{}
+
+// RoomHub holds the multiplayer rooms of every session in the process.
+// Each player is in at most one room.
+type RoomHub struct {
+	mu       sync.Mutex
+	rooms    map[string]*room
+	memberOf map[*Session]*room
+}
+
+var roomHub = NewRoomHub()
+
+func NewRoomHub() *RoomHub {
+	return &RoomHub{
+		rooms:    map[string]*room{},
+		memberOf: map[*Session]*room{},
+	}
+}
+
+// roomCodeLetters leaves out I and O, which are easily read as digits.
+const roomCodeLetters = "ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+func (h *RoomHub) newRoomCode() string {
+	for {
+		code := make([]byte, 4)
+		for i := range code {
+			code[i] = roomCodeLetters[rand.Intn(len(roomCodeLetters))]
+		}
+		if _, ok := h.rooms[string(code)]; !ok {
+			return string(code)
+		}
+	}
+}
+
+// Create opens a room hosted by session, leaving any room it was in.
+func (h *RoomHub) Create(session *Session, mode roomMode, difficulty Difficulty) roomStatus {
+	// The puzzle is generated before taking the lock since the harder ones
+	// take a moment.
+	seed := newSeed()
+	puzzle, solution := generateSudoku(seed, difficulty)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.leave(session)
+	r := &room{
+		code:       h.newRoomCode(),
+		mode:       mode,
+		difficulty: difficulty,
+		seed:       seed,
+		puzzle:     puzzle,
+		solution:   solution,
+		board:      puzzle,
+		host:       session,
+		members:    []*member{{session: session, name: session.DisplayName()}},
+	}
+	for i := 0; i < sudokuLen; i++ {
+		for j := 0; j < sudokuLen; j++ {
+			if puzzle[i][j] == 0 {
+				r.cells++
+			}
+		}
+	}
+	h.rooms[r.code] = r
+	h.memberOf[session] = r
+	programs.broadcast(roomsChangedMsg{})
+	return r.status()
+}
+
+// Join adds session to a room that hasn't started yet.
+func (h *RoomHub) Join(session *Session, code string) (roomStatus, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	r, ok := h.rooms[code]
+	if !ok {
+		return roomStatus{}, errors.New("that room no longer exists")
+	}
+	if r.state != roomWaiting {
+		return roomStatus{}, errors.New("that room's game has already started")
+	}
+	if h.memberOf[session] == r {
+		return r.status(), nil
+	}
+	h.leave(session)
+	r.members = append(r.members, &member{session: session, name: session.DisplayName()})
+	h.memberOf[session] = r
+	h.notify(r)
+	programs.broadcast(roomsChangedMsg{})
+	return r.status(), nil
+}
+
+// Start begins the game of the room session is hosting.
+func (h *RoomHub) Start(session *Session) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	r := h.memberOf[session]
+	if r == nil || r.state != roomWaiting {
+		return errors.New("you aren't waiting in a room")
+	}
+	if r.host != session {
+		return errors.New("only the host can start the game")
+	}
+	r.state = roomRunning
+	h.notify(r)
+	programs.broadcast(roomsChangedMsg{})
+	return nil
+}
+
+// Leave takes session out of its room, if it's in one.
+func (h *RoomHub) Leave(session *Session) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.leave(session)
+}
+
+func (h *RoomHub) leave(session *Session) {
+	r := h.memberOf[session]
+	if r == nil {
+		return
+	}
+	delete(h.memberOf, session)
+
+	for i, m := range r.members {
+		if m.session == session {
+			if r.state == roomWaiting {
+				r.members = append(r.members[:i:i], r.members[i+1:]...)
+			} else {
+				m.left = true
+			}
+			break
+		}
+	}
+	present := 0
+	for _, m := range r.members {
+		if m.left {
+			continue
+		}
+		// A host who leaves hands the room to the next member.
+		if present == 0 && r.host == session {
+			r.host = m.session
+		}
+		present++
+	}
+	if present == 0 {
+		delete(h.rooms, r.code)
+	} else {
+		h.notify(r)
+	}
+	if r.state == roomWaiting {
+		programs.broadcast(roomsChangedMsg{})
+	}
+}
+
+// Open returns the rooms that can still be joined, sorted by code.
+func (h *RoomHub) Open() []roomStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var open []roomStatus
+	for _, r := range h.rooms {
+		if r.state == roomWaiting {
+			open = append(open, r.status())
+		}
+	}
+	sort.Slice(open, func(i, j int) bool { return open[i].code < open[j].code })
+	return open
+}
+
+// running returns the running room session is in, and its member, if any.
+// It must be called with the lock held.
+func (h *RoomHub) running(session *Session) (*room, *member) {
+	r := h.memberOf[session]
+	if r == nil || r.state != roomRunning {
+		return nil, nil
+	}
+	for _, m := range r.members {
+		if m.session == session {
+			return r, m
+		}
+	}
+	return nil, nil
+}
+
+// notify sends the room's state to the members still in it. It must be
+// called with the lock held.
+func (h *RoomHub) notify(r *room) {
+	r.version++
+	status := r.status()
+	for _, m := range r.members {
+		if !m.left {
+			m.session.send(roomChangedMsg{status})
+		}
+	}
+}
+
+func (r *room) status() roomStatus {
+	s := roomStatus{
+		code:       r.code,
+		mode:       r.mode,
+		difficulty: r.difficulty,
+		seed:       r.seed,
+		puzzle:     r.puzzle,
+		solution:   r.solution,
+		cells:      r.cells,
+		host:       r.host,
+		state:      r.state,
+		version:    r.version,
+		board:      r.board,
+		owners:     r.owners,
+		solved:     r.solved,
+	}
+	for _, m := range r.members {
+		ms := memberStatus{
+			session: m.session,
+			name:    m.name,
+			left:    m.left,
+			filled:  m.filled,
+			place:   m.place,
+			time:    m.time,
+			cursor:  m.cursor,
+		}
+		for i := 0; i < sudokuLen; i++ {
+			for j := 0; j < sudokuLen; j++ {
+				if r.owners[i][j] == m.session {
+					ms.owned++
+				}
+			}
+		}
+		s.members = append(s.members, ms)
+	}
+	return s
+}
+
+func (s roomStatus) hostName() string {
+	for _, m := range s.members {
+		if m.session == s.host {
+			return m.name
+		}
+	}
+	return ""
+}
+
+// inRoom reports whether the game is played in a room of the given mode.
+func (m GameModel) inRoom(mode roomMode) bool {
+	return m.room != nil && m.room.mode == mode
+}
+
+// shareMove tells the game's room about a move the player made, or undid if
+// forward is false. A race only learns how far the player got, while a
+// co-op game gets the digits.
+func (m *GameModel) shareMove(mv move, forward bool) {
+	switch {
+	case m.inRoom(raceMode):
+		m.reportRaceProgress()
+	case m.inRoom(coopMode):
+		m.shareCoopMove(mv, forward)
+	}
+}
+
+// updateRoom takes in a newer state of the game's room.
+func (m *GameModel) updateRoom(status roomStatus) {
+	if m.room == nil || status.code != m.room.code || status.version < m.room.version {
+		return
+	}
+	if m.room.mode == coopMode {
+		m.syncCoopBoard(status)
+	}
+	m.room = &status
+}
+
+// leaveRoom takes the player out of the game's room, if it has one.
+func (m GameModel) leaveRoom() {
+	if m.room != nil {
+		roomHub.Leave(m.session)
+	}
+}
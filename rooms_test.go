@@ -0,0 +1,35 @@
+package main
+
+import "testing"
+
+func TestRoomLobby(t *testing.T) {
+	h := NewRoomHub()
+	ann, bob, cat := &Session{Username: "ann"}, &Session{Username: "bob"}, &Session{Username: "cat"}
+	code := h.Create(ann, raceMode, Easy).code
+	for _, s := range []*Session{bob, cat} {
+		if _, err := h.Join(s, code); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The host leaving before the start hands the room to the next to
+	// join, and takes them out of the room altogether.
+	h.Leave(ann)
+	open := h.Open()
+	if len(open) != 1 || open[0].host != bob || len(open[0].members) != 2 {
+		t.Fatalf("after the host left the lobby shows %+v", open)
+	}
+
+	if err := h.Start(cat); err == nil {
+		t.Error("a member who isn't the host started the game")
+	}
+	if err := h.Start(bob); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.Join(ann, code); err == nil {
+		t.Error("a player joined a room whose game had started")
+	}
+	if len(h.Open()) != 0 {
+		t.Error("a started room is still listed as open")
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+)
+
+const savesDir = "saves"
+
+// SavedGame is an in-progress game persisted so that a player can resume it
+// after their connection drops.
+type SavedGame struct {
+	Board        [sudokuLen][sudokuLen]int        `json:"board"`
+	Solution     [sudokuLen][sudokuLen]int        `json:"solution"`
+	InitialBoard [sudokuLen][sudokuLen]int        `json:"initialBoard"`
+	Notes        [sudokuLen][sudokuLen]candidates `json:"notes"`
+	Difficulty   Difficulty                       `json:"difficulty"`
+	Elapsed      time.Duration                    `json:"elapsed"`
+	HintsUsed    int                              `json:"hintsUsed"`
+	Assist       AssistLevel                      `json:"assist"`
+	Mistakes     int                              `json:"mistakes"`
+	MistakeLimit int                              `json:"mistakeLimit"`
+	Moves        int                              `json:"moves"`
+	Daily        string                           `json:"daily,omitempty"`
+	Seed         int64                            `json:"seed"`
+	Unranked     string                           `json:"unranked,omitempty"`
+	SavedAt      time.Time                        `json:"savedAt"`
+}
+
+// savedGamePath returns the save file for a player. IDs are hashed since
+// key fingerprints and usernames aren't safe to use as file names.
+func savedGamePath(playerID string) string {
+	return filepath.Join(config.dataPath(savesDir), fmt.Sprintf("%x.json", sha256.Sum256([]byte(playerID))))
+}
+
+func (g *SavedGame) SaveToFile(filename string) error {
+	data, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(filename, data, 0644)
+}
+
+// LoadSavedGameFromFile returns nil without an error if there is no save.
+func LoadSavedGameFromFile(filename string) (*SavedGame, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var game SavedGame
+	err = json.Unmarshal(data, &game)
+	if err != nil {
+		return nil, err
+	}
+	return &game, nil
+}
+
+func hasSavedGame(playerID string) bool {
+	_, err := os.Stat(savedGamePath(playerID))
+	return err == nil
+}
+
+func deleteSavedGame(playerID string) error {
+	err := os.Remove(savedGamePath(playerID))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// saveProgress persists the game so it can be continued later. Multiplayer
+// games can't be continued, so they aren't saved.
+func (m GameModel) saveProgress() {
+	if m.session == nil || m.room != nil || m.state == Won || m.state == Lost {
+		return
+	}
+	game := SavedGame{
+		Board:        m.board,
+		Solution:     m.solution,
+		InitialBoard: m.initialBoard,
+		Notes:        m.notes,
+		Difficulty:   m.difficulty,
+		Elapsed:      m.elapsed(),
+		HintsUsed:    m.hintsUsed,
+		Assist:       m.assist,
+		Mistakes:     m.mistakes,
+		MistakeLimit: m.mistakeLimit,
+		Moves:        m.moves,
+		Daily:        m.daily,
+		Seed:         m.seed,
+		Unranked:     m.unranked,
+		SavedAt:      time.Now(),
+	}
+	if err := game.SaveToFile(savedGamePath(m.session.PlayerID)); err != nil {
+		log.Error("could not save game", "error", err)
+	}
+}
+
+// saveOnQuit is a program filter that saves the game being played when the
+// program quits. The SSH server quits the program when the connection drops,
+// which the game itself never hears about, so without this the time played
+// since the last save would be lost.
+func saveOnQuit(model tea.Model, msg tea.Msg) tea.Msg {
+	if _, ok := msg.(tea.QuitMsg); ok {
+		switch m := model.(type) {
+		case GameModel:
+			m.saveProgress()
+		case *GameModel:
+			m.saveProgress()
+		}
+	}
+	return msg
+}
+
+// NewGameModelFromSave restores a saved game, picking the clock up from the
+// saved elapsed time.
+func NewGameModelFromSave(width, height int, session *Session, game *SavedGame) *GameModel {
+	m := newGameModel(width, height, game.Difficulty, game.InitialBoard, game.Solution, session)
+	m.board = game.Board
+	m.notes = game.Notes
+	m.elapsedBefore = game.Elapsed
+	m.hintsUsed = game.HintsUsed
+	m.assist = game.Assist
+	m.mistakes = game.Mistakes
+	m.mistakeLimit = game.MistakeLimit
+	m.moves = game.Moves
+	m.daily = game.Daily
+	m.seed = game.Seed
+	m.unranked = game.Unranked
+	if m.daily != "" {
+		m.leaderboard = dailyLeaderboardService
+	}
+	m.refreshState()
+	return m
+}
+
+// discardSave removes the player's save once the game can't be continued.
+// A multiplayer game leaves the save of the player's other game alone.
+func (m GameModel) discardSave() {
+	if m.session == nil || m.room != nil {
+		return
+	}
+	if err := deleteSavedGame(m.session.PlayerID); err != nil {
+		log.Error("could not delete saved game", "error", err)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// useTestDataDir points the data directory at an empty directory for the
+// length of the test.
+func useTestDataDir(t *testing.T) {
+	t.Helper()
+	old := config
+	config = DefaultConfig()
+	config.DataDir = t.TempDir()
+	t.Cleanup(func() { config = old })
+}
+
+// A dropped connection quits the program without the game seeing a key, so
+// the filter has to save the time played since the last move.
+func TestSaveOnQuit(t *testing.T) {
+	useTestDataDir(t)
+	m := newTestGame(t)
+	m.session = &Session{PlayerID: "SHA256:test"}
+	m.elapsedBefore = 3 * time.Minute
+	m.resumeClock()
+
+	// The filter only acts on the quit, and passes every message on.
+	if msg := saveOnQuit(*m, tickMsg{}); msg != (tickMsg{}) || hasSavedGame("SHA256:test") {
+		t.Fatal("the filter saved on a message other than quitting")
+	}
+	saveOnQuit(*m, tea.QuitMsg{})
+
+	saved, err := LoadSavedGameFromFile(savedGamePath("SHA256:test"))
+	if err != nil || saved == nil {
+		t.Fatalf("no game was saved on quitting: %v", err)
+	}
+	if saved.Elapsed < 3*time.Minute || saved.Board != m.board {
+		t.Errorf("the save has %v on the clock; want at least 3m0s", saved.Elapsed)
+	}
+}
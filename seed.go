@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// seedBits keeps seed codes short enough to read out to a friend.
+const seedBits = 40
+
+func newSeed() int64 {
+	return rand.Int63n(1 << seedBits)
+}
+
+// seedCode is the shareable form of a puzzle: its difficulty's initial
+// followed by the seed in base 36, e.g. "H-4FZ2K9Q".
+func seedCode(seed int64, difficulty Difficulty) string {
+	return fmt.Sprintf("%s-%s", difficulty.String()[:1], strings.ToUpper(strconv.FormatInt(seed, 36)))
+}
+
+func parseSeedCode(code string) (int64, Difficulty, error) {
+	prefix, digits, ok := strings.Cut(strings.TrimSpace(code), "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("seed codes look like H-4FZ2K9Q")
+	}
+	difficulty := Difficulty(-1)
+	for d := Easy; d <= Hard; d++ {
+		if strings.EqualFold(prefix, d.String()[:1]) {
+			difficulty = d
+		}
+	}
+	if difficulty < 0 {
+		return 0, 0, fmt.Errorf("unknown difficulty %q", prefix)
+	}
+	seed, err := strconv.ParseInt(digits, 36, 64)
+	if err != nil || seed < 0 || seed >= 1<<seedBits {
+		return 0, 0, fmt.Errorf("invalid seed %q", digits)
+	}
+	return seed, difficulty, nil
+}
+
+// NewSeededGameModel starts the puzzle for a seed code. Anyone can replay a
+// seed they've already solved, so these games are unranked. Today's daily
+// puzzles can't be started from their seeds, which would let players
+// practise them before their ranked attempt.
+func NewSeededGameModel(width, height int, code string, session *Session) (*GameModel, error) {
+	seed, difficulty, err := parseSeedCode(code)
+	if err != nil {
+		return nil, err
+	}
+	if seed == dailySeed(today(), difficulty) {
+		return nil, errors.New("that's today's daily puzzle, play it from the menu")
+	}
+	board, solution := generateSudoku(seed, difficulty)
+	m := newGameModel(width, height, difficulty, board, solution, session)
+	m.seed = seed
+	m.unranked = "it was started from a seed code"
+	return m, nil
+}
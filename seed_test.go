@@ -0,0 +1,63 @@
+package main
+
+import "testing"
+
+func TestSeedCodeRoundTrip(t *testing.T) {
+	// The largest seed is the longest code players have to type.
+	const maxSeed = 1<<seedBits - 1
+	if code := seedCode(maxSeed, Hard); code != "H-E13WU1OF" {
+		t.Errorf("seedCode(maxSeed, Hard) = %q", code)
+	}
+	for _, seed := range []int64{0, 35, maxSeed} {
+		for d := Easy; d <= Hard; d++ {
+			code := seedCode(seed, d)
+			gotSeed, gotDifficulty, err := parseSeedCode(code)
+			if err != nil || gotSeed != seed || gotDifficulty != d {
+				t.Errorf("parseSeedCode(%q) = %d, %v, %v; want %d, %v", code, gotSeed, gotDifficulty, err, seed, d)
+			}
+		}
+	}
+}
+
+// Codes are read out and retyped, so case and surrounding space don't
+// matter.
+func TestParseSeedCodeAsTyped(t *testing.T) {
+	seed, difficulty, err := parseSeedCode("  h-e13wu1of\t")
+	if err != nil || seed != 1<<seedBits-1 || difficulty != Hard {
+		t.Errorf("parseSeedCode = %d, %v, %v", seed, difficulty, err)
+	}
+}
+
+func TestParseSeedCodeRejects(t *testing.T) {
+	for _, code := range []string{
+		"",
+		"HE13WU1OF",       // no dash
+		"X-E13WU1OF",      // no such difficulty
+		"Hard-1",          // the difficulty is only its initial
+		"H-",              // no seed
+		"H-E13WU1OG",      // one past the largest seed
+		"H-ZZZZZZZZZZZZZ", // too big for an int64
+		"H--1",
+		"H-1 2",
+	} {
+		if seed, d, err := parseSeedCode(code); err == nil {
+			t.Errorf("parseSeedCode(%q) = %d, %v; want an error", code, seed, d)
+		}
+	}
+}
+
+func TestSeededGameRejectsTodaysDaily(t *testing.T) {
+	old := dailySecret
+	dailySecret = "test"
+	t.Cleanup(func() { dailySecret = old })
+
+	code := seedCode(dailySeed(today(), Medium), Medium)
+	if _, err := NewSeededGameModel(80, 24, code, &Session{}); err == nil {
+		t.Errorf("today's daily puzzle was started from its seed code %s", code)
+	}
+	// The same seed at another difficulty is a different puzzle.
+	code = seedCode(dailySeed(today(), Medium), Easy)
+	if _, err := NewSeededGameModel(80, 24, code, &Session{}); err != nil {
+		t.Errorf("NewSeededGameModel(%s): %v", code, err)
+	}
+}
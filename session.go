@@ -0,0 +1,64 @@
+package main
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/ssh"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// Session holds what we know about the player behind one program, shared by
+// every model that program moves through.
+type Session struct {
+	PlayerID string
+	// Username is the name the player logged in with.
+	Username string
+	// KeyFingerprint is empty for players who didn't log in with a key;
+	// they can play but not post scores.
+	KeyFingerprint string
+	// Name is the display name bound to KeyFingerprint, if one was chosen.
+	Name     string
+	Settings Settings
+	// program runs the session's models, so that other sessions can send
+	// them messages.
+	program *tea.Program
+}
+
+// NewSSHSession identifies the player by their public key fingerprint, or
+// by their username when they didn't authenticate with a key.
+func NewSSHSession(s ssh.Session) *Session {
+	session := &Session{PlayerID: "user:" + s.User(), Username: s.User()}
+	if pk := s.PublicKey(); pk != nil {
+		session.KeyFingerprint = gossh.FingerprintSHA256(pk)
+		session.PlayerID = session.KeyFingerprint
+		session.Name, _ = players.NameFor(session.KeyFingerprint)
+	}
+	session.Settings = playerSettings.For(session.PlayerID)
+	return session
+}
+
+// KeyMap returns the player's chosen key map.
+func (s *Session) KeyMap() KeyMap {
+	return keyMaps.Get(s.Settings.KeyMap)
+}
+
+// Theme returns the player's chosen theme.
+func (s *Session) Theme() *Theme {
+	return themes.Get(s.Settings.Theme)
+}
+
+// DisplayName is how other players see this one: by their chosen name, or
+// else by the name they logged in with.
+func (s *Session) DisplayName() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return s.Username
+}
+
+// send delivers msg to the session's program. Like programRegistry's
+// broadcast, it doesn't wait for the program to read it.
+func (s *Session) send(msg tea.Msg) {
+	if s.program != nil {
+		go s.program.Send(msg)
+	}
+}
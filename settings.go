@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+const settingsFileName = "sudoku_settings.json"
+
+// AssistLevel is how much the game points out wrong digits while playing.
+type AssistLevel int
+
+const (
+	// AssistOff only checks the board once it is full.
+	AssistOff AssistLevel = iota
+	// AssistConflicts flags digits repeated in a row, column or box as they
+	// are typed. It follows from the rules alone, so it gives nothing away
+	// that the player couldn't see themselves.
+	AssistConflicts
+	// AssistStrict flags any digit that differs from the solution.
+	AssistStrict
+)
+
+func (a AssistLevel) String() string {
+	return [...]string{"Off", "Conflicts", "Strict"}[a]
+}
+
+// Settings are a player's preferences, remembered between sessions.
+type Settings struct {
+	Assist AssistLevel `json:"assist"`
+	// MistakeLimit is how many mistakes lose a game, or 0 for no limit.
+	MistakeLimit int `json:"mistakeLimit"`
+	// HighlightPeers shades the row, column and box of the cursor.
+	HighlightPeers bool `json:"highlightPeers"`
+	// HighlightMatches shades every cell holding the cursor cell's digit.
+	HighlightMatches bool `json:"highlightMatches"`
+	// Theme is the name of the player's color scheme.
+	Theme string `json:"theme"`
+	// KeyMap is the name of the player's key bindings.
+	KeyMap string `json:"keyMap"`
+	// AllowSpectators lists the player's games for others to watch.
+	AllowSpectators bool `json:"allowSpectators"`
+}
+
+func DefaultSettings() Settings {
+	return Settings{
+		Assist:           AssistOff,
+		MistakeLimit:     0,
+		HighlightPeers:   true,
+		HighlightMatches: true,
+		Theme:            darkTheme.Name,
+		KeyMap:           Keys.Name,
+	}
+}
+
+// UnmarshalJSON starts from the defaults, so that settings added since a
+// player last saved theirs get their default values.
+func (s *Settings) UnmarshalJSON(data []byte) error {
+	type plain Settings
+	p := plain(DefaultSettings())
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	*s = Settings(p)
+	return nil
+}
+
+// SettingsRegistry stores the settings of every player by player ID.
+type SettingsRegistry struct {
+	mu       sync.Mutex
+	filename string
+	Players  map[string]Settings `json:"players"`
+}
+
+var playerSettings = NewSettingsRegistry(settingsFileName)
+
+func NewSettingsRegistry(filename string) *SettingsRegistry {
+	return &SettingsRegistry{
+		filename: filename,
+		Players:  map[string]Settings{},
+	}
+}
+
+func LoadSettingsRegistryFromFile(filename string) (*SettingsRegistry, error) {
+	registry := NewSettingsRegistry(filename)
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return registry, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, registry); err != nil {
+		return nil, err
+	}
+	if registry.Players == nil {
+		registry.Players = map[string]Settings{}
+	}
+	return registry, nil
+}
+
+// For returns a player's settings, or the defaults if they never changed
+// them.
+func (r *SettingsRegistry) For(playerID string) Settings {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s, ok := r.Players[playerID]; ok {
+		return s
+	}
+	return DefaultSettings()
+}
+
+func (r *SettingsRegistry) Set(playerID string, s Settings) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Players[playerID] = s
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(r.filename, data, 0644)
+}
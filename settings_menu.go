@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// settingOption is one row of the settings screen. change moves the value
+// forwards or backwards through its choices.
+type settingOption struct {
+	name   string
+	value  func(Settings) string
+	change func(s *Settings, delta int)
+}
+
+var settingOptions = []settingOption{
+	{
+		name:  "Assist",
+		value: func(s Settings) string { return s.Assist.String() },
+		change: func(s *Settings, delta int) {
+			s.Assist = AssistLevel(cycle(int(s.Assist), delta, int(AssistStrict)+1))
+		},
+	},
+	{
+		name:  "Mistake limit",
+		value: func(s Settings) string { return formatMistakeLimit(s.MistakeLimit) },
+		change: func(s *Settings, delta int) {
+			i := 0
+			for j, limit := range mistakeLimits {
+				if limit == s.MistakeLimit {
+					i = j
+				}
+			}
+			s.MistakeLimit = mistakeLimits[cycle(i, delta, len(mistakeLimits))]
+		},
+	},
+	{
+		name:   "Highlight peers",
+		value:  func(s Settings) string { return formatOnOff(s.HighlightPeers) },
+		change: func(s *Settings, delta int) { s.HighlightPeers = !s.HighlightPeers },
+	},
+	{
+		name:   "Highlight matches",
+		value:  func(s Settings) string { return formatOnOff(s.HighlightMatches) },
+		change: func(s *Settings, delta int) { s.HighlightMatches = !s.HighlightMatches },
+	},
+	{
+		name:  "Theme",
+		value: func(s Settings) string { return themes.Get(s.Theme).Name },
+		change: func(s *Settings, delta int) {
+			names := themes.Names()
+			i := 0
+			for j, name := range names {
+				if name == s.Theme {
+					i = j
+				}
+			}
+			s.Theme = names[cycle(i, delta, len(names))]
+		},
+	},
+	{
+		name:  "Keys",
+		value: func(s Settings) string { return keyMaps.Get(s.KeyMap).Name },
+		change: func(s *Settings, delta int) {
+			names := keyMaps.Names()
+			i := 0
+			for j, name := range names {
+				if name == s.KeyMap {
+					i = j
+				}
+			}
+			s.KeyMap = names[cycle(i, delta, len(names))]
+		},
+	},
+	{
+		name:   "Allow spectators",
+		value:  func(s Settings) string { return formatOnOff(s.AllowSpectators) },
+		change: func(s *Settings, delta int) { s.AllowSpectators = !s.AllowSpectators },
+	},
+}
+
+func formatOnOff(on bool) string {
+	if on {
+		return "On"
+	}
+	return "Off"
+}
+
+// cycle steps value by delta, wrapping around n choices.
+func cycle(value, delta, n int) int {
+	return ((value+delta)%n + n) % n
+}
+
+// SettingsModel lets the player change their settings. Changes are saved as
+// soon as they're made and apply to the next game started.
+type SettingsModel struct {
+	cursor        int
+	err           string
+	width, height int
+	session       *Session
+	help          help.Model
+}
+
+func NewSettingsModel(width, height int, session *Session) *SettingsModel {
+	return &SettingsModel{
+		width:   width,
+		height:  height,
+		session: session,
+		help:    newHelp(session.Theme(), width),
+	}
+}
+
+func (m SettingsModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m SettingsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		keys := m.session.KeyMap()
+		switch {
+		case key.Matches(msg, keys.ForceQuit):
+			return m, tea.Quit
+		case key.Matches(msg, keys.Back):
+			return NewMenuModel(m.width, m.height, m.session), nil
+		case key.Matches(msg, keys.Up):
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case key.Matches(msg, keys.Down):
+			if m.cursor < len(settingOptions)-1 {
+				m.cursor++
+			}
+		case key.Matches(msg, keys.Left):
+			m.change(-1)
+		case key.Matches(msg, keys.Right), key.Matches(msg, keys.Select):
+			m.change(1)
+		case key.Matches(msg, keys.Help):
+			m.help.ShowAll = !m.help.ShowAll
+		}
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.help.Width = msg.Width
+	}
+	return m, nil
+}
+
+func (m *SettingsModel) change(delta int) {
+	settingOptions[m.cursor].change(&m.session.Settings, delta)
+	m.err = ""
+	// The theme may have changed.
+	m.help.Styles = newHelp(m.session.Theme(), m.width).Styles
+	if err := playerSettings.Set(m.session.PlayerID, m.session.Settings); err != nil {
+		m.err = fmt.Sprintf("Could not save settings: %v", err)
+	}
+}
+
+func (m SettingsModel) View() string {
+	var s strings.Builder
+	s.WriteString(lipgloss.NewStyle().Bold(true).Render("Settings") + "\n\n")
+	for i, option := range settingOptions {
+		cursor := " "
+		if m.cursor == i {
+			cursor = ">"
+		}
+		s.WriteString(fmt.Sprintf("%s %-20s ◀ %-10s ▶\n", cursor, option.name, option.value(m.session.Settings)))
+	}
+	k := m.session.KeyMap()
+	s.WriteString("\n" + m.help.View(bindingHelp{
+		relabel(k.Up, "choose"), relabel(k.Down, "choose"),
+		relabel(k.Left, "change"), relabel(k.Right, "change"),
+		relabel(k.Back, "back to menu"), k.Help,
+	}))
+	if m.err != "" {
+		s.WriteString("\n\n" + m.session.Theme().failureStyle().Render(m.err))
+	}
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, s.String())
+}
@@ -0,0 +1,670 @@
+package main
+
+import (
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// Technique is a human solving technique. Techniques are ordered from the
+// easiest to the hardest, so a puzzle's grade is simply the largest one used.
+type Technique int
+
+const (
+	NakedSingle Technique = iota
+	HiddenSingle
+	NakedPair
+	HiddenPair
+	Pointing
+	BoxLineReduction
+	XWing
+	Swordfish
+	XYWing
+	XYChain
+)
+
+func (t Technique) String() string {
+	return [...]string{
+		"Naked single",
+		"Hidden single",
+		"Naked pair",
+		"Hidden pair",
+		"Pointing",
+		"Box/line reduction",
+		"X-Wing",
+		"Swordfish",
+		"XY-Wing",
+		"XY-Chain",
+	}[t]
+}
+
+// Difficulty is the puzzle difficulty implied by needing this technique.
+func (t Technique) Difficulty() Difficulty {
+	switch {
+	case t <= HiddenSingle:
+		return Easy
+	case t <= BoxLineReduction:
+		return Medium
+	default:
+		return Hard
+	}
+}
+
+// candidates is a bit set of the digits 1-9 that may still go in a cell.
+type candidates uint16
+
+const allCandidates candidates = 0x3fe
+
+func (c candidates) has(d int) bool {
+	return c&(1<<d) != 0
+}
+
+func (c candidates) count() int {
+	return bits.OnesCount16(uint16(c))
+}
+
+func (c candidates) digits() []int {
+	var ds []int
+	for d := 1; d <= sudokuLen; d++ {
+		if c.has(d) {
+			ds = append(ds, d)
+		}
+	}
+	return ds
+}
+
+func (c candidates) String() string {
+	ds := c.digits()
+	parts := make([]string, len(ds))
+	for i, d := range ds {
+		parts[i] = fmt.Sprint(d)
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+type unit struct {
+	kind  string
+	index int
+	cells [sudokuLen]coordinate
+}
+
+func (u unit) String() string {
+	return fmt.Sprintf("%s %d", u.kind, u.index+1)
+}
+
+var (
+	rows, cols, boxes [sudokuLen]unit
+	// searchOrder lists boxes before lines, which is how most people scan.
+	searchOrder []unit
+	peers       [sudokuLen][sudokuLen][]coordinate
+)
+
+func init() {
+	for i := 0; i < sudokuLen; i++ {
+		rows[i] = unit{kind: "row", index: i}
+		cols[i] = unit{kind: "column", index: i}
+		boxes[i] = unit{kind: "box", index: i}
+		for j := 0; j < sudokuLen; j++ {
+			rows[i].cells[j] = coordinate{i, j}
+			cols[i].cells[j] = coordinate{j, i}
+			boxes[i].cells[j] = coordinate{i/3*3 + j/3, i%3*3 + j%3}
+		}
+	}
+	searchOrder = append(append(append(searchOrder, boxes[:]...), rows[:]...), cols[:]...)
+
+	for i := 0; i < sudokuLen; i++ {
+		for j := 0; j < sudokuLen; j++ {
+			for k := 0; k < sudokuLen; k++ {
+				for l := 0; l < sudokuLen; l++ {
+					a, b := coordinate{i, j}, coordinate{k, l}
+					if sees(a, b) {
+						peers[i][j] = append(peers[i][j], b)
+					}
+				}
+			}
+		}
+	}
+}
+
+func boxIndex(c coordinate) int {
+	return c.row/3*3 + c.col/3
+}
+
+// sees reports whether two different cells share a row, column or box.
+func sees(a, b coordinate) bool {
+	if a == b {
+		return false
+	}
+	return a.row == b.row || a.col == b.col || boxIndex(a) == boxIndex(b)
+}
+
+func (c coordinate) String() string {
+	return fmt.Sprintf("r%dc%d", c.row+1, c.col+1)
+}
+
+type cellDigit struct {
+	coordinate
+	digit int
+}
+
+// step is a single logical deduction: either digits to place or candidates
+// to eliminate, together with the cells that justify it.
+type step struct {
+	technique    Technique
+	placements   []cellDigit
+	eliminations []cellDigit
+	cells        []coordinate
+	explanation  string
+}
+
+type logicSolver struct {
+	board [sudokuLen][sudokuLen]int
+	cands [sudokuLen][sudokuLen]candidates
+}
+
+func newLogicSolver(board [sudokuLen][sudokuLen]int) *logicSolver {
+	s := &logicSolver{board: board}
+	for i := 0; i < sudokuLen; i++ {
+		for j := 0; j < sudokuLen; j++ {
+			if board[i][j] != 0 {
+				continue
+			}
+			c := allCandidates
+			for _, p := range peers[i][j] {
+				c &^= 1 << board[p.row][p.col]
+			}
+			s.cands[i][j] = c
+		}
+	}
+	return s
+}
+
+func (s *logicSolver) place(row, col, digit int) {
+	s.board[row][col] = digit
+	s.cands[row][col] = 0
+	for _, p := range peers[row][col] {
+		s.cands[p.row][p.col] &^= 1 << digit
+	}
+}
+
+func (s *logicSolver) apply(st step) {
+	for _, p := range st.placements {
+		s.place(p.row, p.col, p.digit)
+	}
+	for _, e := range st.eliminations {
+		s.cands[e.row][e.col] &^= 1 << e.digit
+	}
+}
+
+func (s *logicSolver) solved() bool {
+	for i := 0; i < sudokuLen; i++ {
+		for j := 0; j < sudokuLen; j++ {
+			if s.board[i][j] == 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// nextStep returns the easiest deduction available, or false when none of
+// the known techniques make progress.
+func (s *logicSolver) nextStep() (step, bool) {
+	finders := []func() (step, bool){
+		s.findNakedSingle,
+		s.findHiddenSingle,
+		s.findNakedPair,
+		s.findHiddenPair,
+		s.findPointing,
+		s.findBoxLineReduction,
+		func() (step, bool) { return s.findFish(2) },
+		func() (step, bool) { return s.findFish(3) },
+		s.findXYWing,
+		s.findXYChain,
+	}
+	for _, find := range finders {
+		if st, ok := find(); ok {
+			return st, true
+		}
+	}
+	return step{}, false
+}
+
+// gradePuzzle solves board using only logical techniques and reports the
+// hardest technique needed. solved is false if the techniques run out before
+// the grid is complete, meaning the puzzle would need guessing.
+func gradePuzzle(board [sudokuLen][sudokuLen]int) (hardest Technique, solved bool) {
+	s := newLogicSolver(board)
+	for !s.solved() {
+		st, ok := s.nextStep()
+		if !ok {
+			return hardest, false
+		}
+		if st.technique > hardest {
+			hardest = st.technique
+		}
+		s.apply(st)
+	}
+	return hardest, true
+}
+
+func (s *logicSolver) findNakedSingle() (step, bool) {
+	for i := 0; i < sudokuLen; i++ {
+		for j := 0; j < sudokuLen; j++ {
+			if s.board[i][j] == 0 && s.cands[i][j].count() == 1 {
+				d := s.cands[i][j].digits()[0]
+				return step{
+					technique:   NakedSingle,
+					placements:  []cellDigit{{coordinate{i, j}, d}},
+					cells:       []coordinate{{i, j}},
+					explanation: fmt.Sprintf("Naked single: %d is the only candidate left in row %d, column %d", d, i+1, j+1),
+				}, true
+			}
+		}
+	}
+	return step{}, false
+}
+
+func (s *logicSolver) findHiddenSingle() (step, bool) {
+	for _, u := range searchOrder {
+		for d := 1; d <= sudokuLen; d++ {
+			cells := s.cellsWith(u, d)
+			if len(cells) != 1 {
+				continue
+			}
+			c := cells[0]
+			var where string
+			switch u.kind {
+			case "box":
+				where = fmt.Sprintf("row %d, column %d of %s", c.row+1, c.col+1, u)
+			case "row":
+				where = fmt.Sprintf("column %d of %s", c.col+1, u)
+			default:
+				where = fmt.Sprintf("row %d of %s", c.row+1, u)
+			}
+			return step{
+				technique:   HiddenSingle,
+				placements:  []cellDigit{{c, d}},
+				cells:       u.cells[:],
+				explanation: fmt.Sprintf("Hidden single: %d can only go in %s", d, where),
+			}, true
+		}
+	}
+	return step{}, false
+}
+
+func (s *logicSolver) findNakedPair() (step, bool) {
+	for _, u := range searchOrder {
+		for a := 0; a < sudokuLen; a++ {
+			ca := u.cells[a]
+			pair := s.cands[ca.row][ca.col]
+			if pair.count() != 2 {
+				continue
+			}
+			for b := a + 1; b < sudokuLen; b++ {
+				cb := u.cells[b]
+				if s.cands[cb.row][cb.col] != pair {
+					continue
+				}
+				var elims []cellDigit
+				for _, c := range u.cells {
+					if c == ca || c == cb {
+						continue
+					}
+					for _, d := range pair.digits() {
+						if s.cands[c.row][c.col].has(d) {
+							elims = append(elims, cellDigit{c, d})
+						}
+					}
+				}
+				if len(elims) > 0 {
+					return step{
+						technique:    NakedPair,
+						eliminations: elims,
+						cells:        []coordinate{ca, cb},
+						explanation:  fmt.Sprintf("Naked pair: %s and %s both hold %s, so no other cell in %s can", ca, cb, pair, u),
+					}, true
+				}
+			}
+		}
+	}
+	return step{}, false
+}
+
+func (s *logicSolver) findHiddenPair() (step, bool) {
+	for _, u := range searchOrder {
+		for d1 := 1; d1 <= sudokuLen; d1++ {
+			cells := s.cellsWith(u, d1)
+			if len(cells) != 2 {
+				continue
+			}
+			for d2 := d1 + 1; d2 <= sudokuLen; d2++ {
+				other := s.cellsWith(u, d2)
+				if len(other) != 2 || other[0] != cells[0] || other[1] != cells[1] {
+					continue
+				}
+				keep := candidates(1<<d1 | 1<<d2)
+				var elims []cellDigit
+				for _, c := range cells {
+					for _, d := range (s.cands[c.row][c.col] &^ keep).digits() {
+						elims = append(elims, cellDigit{c, d})
+					}
+				}
+				if len(elims) > 0 {
+					return step{
+						technique:    HiddenPair,
+						eliminations: elims,
+						cells:        cells,
+						explanation:  fmt.Sprintf("Hidden pair: %d and %d can only go in %s and %s of %s", d1, d2, cells[0], cells[1], u),
+					}, true
+				}
+			}
+		}
+	}
+	return step{}, false
+}
+
+func (s *logicSolver) findPointing() (step, bool) {
+	for _, box := range boxes {
+		for d := 1; d <= sudokuLen; d++ {
+			cells := s.cellsWith(box, d)
+			if len(cells) < 2 {
+				continue
+			}
+			for _, line := range s.sharedLines(cells) {
+				elims := s.eliminate(line, d, func(c coordinate) bool { return boxIndex(c) == box.index })
+				if len(elims) > 0 {
+					return step{
+						technique:    Pointing,
+						eliminations: elims,
+						cells:        cells,
+						explanation:  fmt.Sprintf("Pointing: in %s, %d is confined to %s, so it can be removed from the rest of that %s", box, d, line, line.kind),
+					}, true
+				}
+			}
+		}
+	}
+	return step{}, false
+}
+
+func (s *logicSolver) findBoxLineReduction() (step, bool) {
+	for _, line := range searchOrder[sudokuLen:] {
+		for d := 1; d <= sudokuLen; d++ {
+			cells := s.cellsWith(line, d)
+			if len(cells) < 2 {
+				continue
+			}
+			b := boxIndex(cells[0])
+			sameBox := true
+			for _, c := range cells[1:] {
+				if boxIndex(c) != b {
+					sameBox = false
+					break
+				}
+			}
+			if !sameBox {
+				continue
+			}
+			elims := s.eliminate(boxes[b], d, func(c coordinate) bool {
+				if line.kind == "row" {
+					return c.row == line.index
+				}
+				return c.col == line.index
+			})
+			if len(elims) > 0 {
+				return step{
+					technique:    BoxLineReduction,
+					eliminations: elims,
+					cells:        cells,
+					explanation:  fmt.Sprintf("Box/line reduction: in %s, %d is confined to %s, so it can be removed from the rest of that box", line, d, boxes[b]),
+				}, true
+			}
+		}
+	}
+	return step{}, false
+}
+
+// findFish looks for X-Wings (size 2) and Swordfish (size 3): size lines in
+// which digit d is confined to the same size cross lines.
+func (s *logicSolver) findFish(size int) (step, bool) {
+	technique := XWing
+	if size == 3 {
+		technique = Swordfish
+	}
+	for _, byRow := range []bool{true, false} {
+		base, cover := rows, cols
+		if !byRow {
+			base, cover = cols, rows
+		}
+		for d := 1; d <= sudokuLen; d++ {
+			var lines []int
+			var masks []uint16
+			for i, u := range base {
+				var mask uint16
+				for _, c := range s.cellsWith(u, d) {
+					if byRow {
+						mask |= 1 << c.col
+					} else {
+						mask |= 1 << c.row
+					}
+				}
+				if n := bits.OnesCount16(mask); n >= 2 && n <= size {
+					lines = append(lines, i)
+					masks = append(masks, mask)
+				}
+			}
+			for _, combo := range combinations(len(lines), size) {
+				var union uint16
+				inBase := make(map[int]bool)
+				var cells []coordinate
+				for _, k := range combo {
+					union |= masks[k]
+					inBase[lines[k]] = true
+					cells = append(cells, s.cellsWith(base[lines[k]], d)...)
+				}
+				if bits.OnesCount16(union) != size {
+					continue
+				}
+				var elims []cellDigit
+				var coverNames []string
+				for i := 0; i < sudokuLen; i++ {
+					if union&(1<<i) == 0 {
+						continue
+					}
+					coverNames = append(coverNames, fmt.Sprint(i+1))
+					elims = append(elims, s.eliminate(cover[i], d, func(c coordinate) bool {
+						if byRow {
+							return inBase[c.row]
+						}
+						return inBase[c.col]
+					})...)
+				}
+				if len(elims) > 0 {
+					var baseNames []string
+					for _, k := range combo {
+						baseNames = append(baseNames, fmt.Sprint(lines[k]+1))
+					}
+					return step{
+						technique:    technique,
+						eliminations: elims,
+						cells:        cells,
+						explanation: fmt.Sprintf("%s: %d in %ss %s is confined to %ss %s, so it can be removed from the rest of those %ss",
+							technique, d, base[0].kind, strings.Join(baseNames, ", "),
+							cover[0].kind, strings.Join(coverNames, ", "), cover[0].kind),
+					}, true
+				}
+			}
+		}
+	}
+	return step{}, false
+}
+
+func (s *logicSolver) findXYWing() (step, bool) {
+	bivalue := s.bivalueCells()
+	for _, pivot := range bivalue {
+		pc := s.cands[pivot.row][pivot.col]
+		for _, w1 := range bivalue {
+			c1 := s.cands[w1.row][w1.col]
+			if !sees(pivot, w1) || (c1&pc).count() != 1 || c1 == pc {
+				continue
+			}
+			for _, w2 := range bivalue {
+				c2 := s.cands[w2.row][w2.col]
+				if w2 == w1 || !sees(pivot, w2) || (c2&pc).count() != 1 || c2 == pc {
+					continue
+				}
+				// The pincers share the digit the pivot lacks, and each shares
+				// a different digit with the pivot.
+				z := c1 &^ pc
+				if c2&^pc != z || c1&c2&pc != 0 {
+					continue
+				}
+				d := z.digits()[0]
+				elims := s.seenByBoth(w1, w2, d)
+				if len(elims) > 0 {
+					return step{
+						technique:    XYWing,
+						eliminations: elims,
+						cells:        []coordinate{pivot, w1, w2},
+						explanation:  fmt.Sprintf("XY-Wing: pivot %s %s with pincers %s %s and %s %s means %d can't go in any cell seeing both pincers", pivot, pc, w1, c1, w2, c2, d),
+					}, true
+				}
+			}
+		}
+	}
+	return step{}, false
+}
+
+const maxChainLength = 12
+
+// findXYChain follows chains of bivalue cells: if the first cell is not x
+// then each following cell is forced, and if the last cell is then x, any
+// cell seeing both ends can't be x.
+func (s *logicSolver) findXYChain() (step, bool) {
+	bivalue := s.bivalueCells()
+	for _, start := range bivalue {
+		for _, x := range s.cands[start.row][start.col].digits() {
+			other := (s.cands[start.row][start.col] &^ (1 << x)).digits()[0]
+			chain := []coordinate{start}
+			visited := map[coordinate]bool{start: true}
+			if st, ok := s.extendChain(bivalue, chain, visited, x, other); ok {
+				return st, true
+			}
+		}
+	}
+	return step{}, false
+}
+
+func (s *logicSolver) extendChain(bivalue []coordinate, chain []coordinate, visited map[coordinate]bool, x, value int) (step, bool) {
+	if len(chain) >= maxChainLength {
+		return step{}, false
+	}
+	last := chain[len(chain)-1]
+	for _, next := range bivalue {
+		c := s.cands[next.row][next.col]
+		if visited[next] || !sees(last, next) || !c.has(value) {
+			continue
+		}
+		forced := (c &^ (1 << value)).digits()[0]
+		chain := append(chain[:len(chain):len(chain)], next)
+		if forced == x && len(chain) >= 3 {
+			if elims := s.seenByBoth(chain[0], next, x); len(elims) > 0 {
+				links := make([]string, len(chain))
+				for i, c := range chain {
+					links[i] = c.String()
+				}
+				return step{
+					technique:    XYChain,
+					eliminations: elims,
+					cells:        chain,
+					explanation:  fmt.Sprintf("XY-Chain: %s forces one end of the chain to be %d, so %d can't go in any cell seeing both ends", strings.Join(links, " → "), x, x),
+				}, true
+			}
+		}
+		visited[next] = true
+		if st, ok := s.extendChain(bivalue, chain, visited, x, forced); ok {
+			return st, true
+		}
+		delete(visited, next)
+	}
+	return step{}, false
+}
+
+// cellsWith returns the empty cells of u that still have d as a candidate.
+func (s *logicSolver) cellsWith(u unit, d int) []coordinate {
+	var cells []coordinate
+	for _, c := range u.cells {
+		if s.cands[c.row][c.col].has(d) {
+			cells = append(cells, c)
+		}
+	}
+	return cells
+}
+
+// sharedLines returns the row and/or column that contains every cell.
+func (s *logicSolver) sharedLines(cells []coordinate) []unit {
+	sameRow, sameCol := true, true
+	for _, c := range cells[1:] {
+		sameRow = sameRow && c.row == cells[0].row
+		sameCol = sameCol && c.col == cells[0].col
+	}
+	var lines []unit
+	if sameRow {
+		lines = append(lines, rows[cells[0].row])
+	}
+	if sameCol {
+		lines = append(lines, cols[cells[0].col])
+	}
+	return lines
+}
+
+// eliminate lists the cells of u, other than those matching keep, that
+// still have d as a candidate.
+func (s *logicSolver) eliminate(u unit, d int, keep func(coordinate) bool) []cellDigit {
+	var elims []cellDigit
+	for _, c := range u.cells {
+		if !keep(c) && s.cands[c.row][c.col].has(d) {
+			elims = append(elims, cellDigit{c, d})
+		}
+	}
+	return elims
+}
+
+func (s *logicSolver) seenByBoth(a, b coordinate, d int) []cellDigit {
+	var elims []cellDigit
+	for _, c := range peers[a.row][a.col] {
+		if c != b && sees(c, b) && s.cands[c.row][c.col].has(d) {
+			elims = append(elims, cellDigit{c, d})
+		}
+	}
+	return elims
+}
+
+func (s *logicSolver) bivalueCells() []coordinate {
+	var cells []coordinate
+	for i := 0; i < sudokuLen; i++ {
+		for j := 0; j < sudokuLen; j++ {
+			if s.cands[i][j].count() == 2 {
+				cells = append(cells, coordinate{i, j})
+			}
+		}
+	}
+	return cells
+}
+
+// combinations returns every k-element subset of 0..n-1 in order.
+func combinations(n, k int) [][]int {
+	var result [][]int
+	var build func(start int, combo []int)
+	build = func(start int, combo []int) {
+		if len(combo) == k {
+			result = append(result, append([]int(nil), combo...))
+			return
+		}
+		for i := start; i < n; i++ {
+			build(i+1, append(combo, i))
+		}
+	}
+	build(0, nil)
+	return result
+}
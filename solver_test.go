@@ -0,0 +1,116 @@
+package main
+
+import "testing"
+
+// testGrid reads an 81-character puzzle line, "." for empty cells.
+func testGrid(t *testing.T, line string) [sudokuLen][sudokuLen]int {
+	t.Helper()
+	if len(line) != sudokuLen*sudokuLen {
+		t.Fatalf("test grid has %d cells", len(line))
+	}
+	var grid [sudokuLen][sudokuLen]int
+	for i, r := range line {
+		if r >= '1' && r <= '9' {
+			grid[i/sudokuLen][i%sudokuLen] = int(r - '0')
+		}
+	}
+	return grid
+}
+
+// solveLogically runs the logic solver to the end, checking that it never
+// places a digit that breaks the rules.
+func solveLogically(t *testing.T, board [sudokuLen][sudokuLen]int) *logicSolver {
+	t.Helper()
+	s := newLogicSolver(board)
+	for !s.solved() {
+		st, ok := s.nextStep()
+		if !ok {
+			break
+		}
+		s.apply(st)
+		for _, p := range st.placements {
+			for _, peer := range peers[p.row][p.col] {
+				if s.board[peer.row][peer.col] == p.digit {
+					t.Fatalf("%v placed %d at %v, which %v already has", st.technique, p.digit, p.coordinate, peer)
+				}
+			}
+		}
+	}
+	return s
+}
+
+func TestGradePuzzleTechniques(t *testing.T) {
+	// Each puzzle can't be solved without the technique it's listed under.
+	puzzles := map[Technique]string{
+		NakedPair:  "4.....938.32.941...953..24.37.6.9..4529..16736.47.3.9.957..83....39..4..24..3.7.9",
+		HiddenPair: "72..96..3...2.5....8...4.2........6.1.65.38.7.4........3.8...9....7.2...2..43..18",
+		XWing:      "1.....569492.561.8.561.924...964.8.1.64.1....218.356.4.4.5...169.5.614.2621.....5",
+		XYWing:     "9..24.....5.69.231.2..5..9..9.7..32...29356.7.7...29...69.2..7351..79.622.7.86..9",
+	}
+	for want, puzzle := range puzzles {
+		t.Run(want.String(), func(t *testing.T) {
+			board := testGrid(t, puzzle)
+			grade, solved := gradePuzzle(board)
+			if !solved || grade != want {
+				t.Fatalf("gradePuzzle = %v, solved %v; want %v", grade, solved, want)
+			}
+			// The puzzles have one solution, so a complete grid in which no
+			// placement broke the rules is it.
+			if s := solveLogically(t, board); !s.solved() {
+				t.Error("the solver didn't finish the puzzle it graded")
+			}
+		})
+	}
+}
+
+// The hardest technique grades a puzzle, not the last one used: the x-wing
+// puzzle finishes with singles.
+func TestGradePuzzleKeepsHardestTechnique(t *testing.T) {
+	board := testGrid(t, "1.....569492.561.8.561.924...964.8.1.64.1....218.356.4.4.5...169.5.614.2621.....5")
+	s := newLogicSolver(board)
+	var last Technique
+	for !s.solved() {
+		st, ok := s.nextStep()
+		if !ok {
+			t.Fatal("the solver got stuck")
+		}
+		last = st.technique
+		s.apply(st)
+	}
+	if grade, _ := gradePuzzle(board); last >= grade {
+		t.Errorf("last technique %v, grade %v; want the grade to be the harder one", last, grade)
+	}
+}
+
+func TestGradePuzzleNeedsGuessing(t *testing.T) {
+	// This puzzle has one solution, but none of the techniques find it.
+	board := testGrid(t, "8..........36......7..9.2...5...7.......457.....1...3...1....68..85...1..9....4..")
+	if _, solved := gradePuzzle(board); solved {
+		t.Fatal("gradePuzzle solved a puzzle that needs guessing")
+	}
+	if s := solveLogically(t, board); s.solved() {
+		t.Fatal("the solver finished a puzzle it can't solve")
+	}
+}
+
+func TestGradePuzzleFullBoard(t *testing.T) {
+	board := testGrid(t, "534678912672195348198342567859761423426853791713924856961537284287419635345286179")
+	if grade, solved := gradePuzzle(board); !solved || grade != NakedSingle {
+		t.Errorf("gradePuzzle = %v, solved %v; want a solved board to need nothing", grade, solved)
+	}
+}
+
+func TestTechniqueDifficulty(t *testing.T) {
+	// The techniques on either side of each difficulty boundary.
+	for technique, want := range map[Technique]Difficulty{
+		HiddenSingle:     Easy,
+		NakedPair:        Medium,
+		BoxLineReduction: Medium,
+		XWing:            Hard,
+		XYChain:          Hard,
+	} {
+		if got := technique.Difficulty(); got != want {
+			t.Errorf("%v.Difficulty() = %v; want %v", technique, got, want)
+		}
+	}
+}
@@ -0,0 +1,390 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// gameSnapshot is what spectators see of a game: the player's board and
+// clock, but not the solution, which would give the puzzle away.
+type gameSnapshot struct {
+	session      *Session
+	name         string
+	difficulty   Difficulty
+	seedText     string
+	board        [sudokuLen][sudokuLen]int
+	initialBoard [sudokuLen][sudokuLen]int
+	notes        [sudokuLen][sudokuLen]candidates
+	notesMode    bool
+	// errs are the cells whose digit repeats in their row, column or box.
+	// Cells flagged against the solution aren't sent, since that would tell
+	// spectators which digits are wrong.
+	errs          [sudokuLen][sudokuLen]bool
+	cursor        coordinate
+	state         GameState
+	cellsLeft     int
+	hintsUsed     int
+	mistakes      int
+	mistakeLimit  int
+	elapsedBefore time.Duration
+	startTime     time.Time
+	clockRunning  bool
+}
+
+func (g gameSnapshot) elapsed() time.Duration {
+	if !g.clockRunning {
+		return g.elapsedBefore
+	}
+	return g.elapsedBefore + time.Since(g.startTime)
+}
+
+// gameSnapshotMsg is sent to a game's spectators whenever it changes.
+type gameSnapshotMsg struct {
+	game gameSnapshot
+}
+
+// spectatedGameEndedMsg is sent to a game's spectators when the player
+// leaves it.
+type spectatedGameEndedMsg struct {
+	player *Session
+}
+
+// liveGamesChangedMsg is sent to every session when a game can newly be
+// watched or no longer can, so that spectators' lists redraw.
+type liveGamesChangedMsg struct{}
+
+// LiveGames holds the latest snapshot of every game whose player lets others
+// watch, and who is watching each.
+type LiveGames struct {
+	mu       sync.Mutex
+	games    map[*Session]gameSnapshot
+	watchers map[*Session]map[*Session]bool
+}
+
+var liveGames = NewLiveGames()
+
+func NewLiveGames() *LiveGames {
+	return &LiveGames{
+		games:    map[*Session]gameSnapshot{},
+		watchers: map[*Session]map[*Session]bool{},
+	}
+}
+
+// Publish records the latest state of a player's game and sends it to its
+// spectators.
+func (l *LiveGames) Publish(game gameSnapshot) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	old, ok := l.games[game.session]
+	if ok && old == game {
+		return
+	}
+	l.games[game.session] = game
+	for spectator := range l.watchers[game.session] {
+		spectator.send(gameSnapshotMsg{game})
+	}
+	if !ok {
+		programs.broadcast(liveGamesChangedMsg{})
+	}
+}
+
+// Remove ends a player's game for its spectators.
+func (l *LiveGames) Remove(player *Session) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.games[player]; !ok {
+		return
+	}
+	delete(l.games, player)
+	for spectator := range l.watchers[player] {
+		spectator.send(spectatedGameEndedMsg{player})
+	}
+	delete(l.watchers, player)
+	programs.broadcast(liveGamesChangedMsg{})
+}
+
+// List returns the games session can watch, by player name. Games the same
+// player is playing in their other sessions aren't listed.
+func (l *LiveGames) List(session *Session) []gameSnapshot {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var games []gameSnapshot
+	for player, game := range l.games {
+		if player != session && player.PlayerID != session.PlayerID {
+			games = append(games, game)
+		}
+	}
+	sort.Slice(games, func(i, j int) bool { return games[i].name < games[j].name })
+	return games
+}
+
+// Watch starts sending a player's game to spectator and returns its current
+// state, if the game is still going.
+func (l *LiveGames) Watch(spectator, player *Session) (gameSnapshot, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	game, ok := l.games[player]
+	if !ok {
+		return gameSnapshot{}, false
+	}
+	if l.watchers[player] == nil {
+		l.watchers[player] = map[*Session]bool{}
+	}
+	l.watchers[player][spectator] = true
+	return game, true
+}
+
+// Unwatch stops sending games to spectator.
+func (l *LiveGames) Unwatch(spectator *Session) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, spectators := range l.watchers {
+		delete(spectators, spectator)
+	}
+}
+
+// Leave removes everything of a session that disconnected.
+func (l *LiveGames) Leave(session *Session) {
+	l.Remove(session)
+	l.Unwatch(session)
+}
+
+// publish shows spectators the game as it is now. Only players who allow it
+// are watched, and never in multiplayer rooms, where watching a racer would
+// reveal their digits, nor in daily puzzles, which spectators may not have
+// had their ranked attempt at yet. Like the player, spectators don't see the
+// board while the game is paused.
+func (m GameModel) publish() {
+	if !m.spectatable || m.room != nil || m.session == nil || m.daily != "" {
+		return
+	}
+	game := gameSnapshot{
+		session:       m.session,
+		name:          m.session.DisplayName(),
+		difficulty:    m.difficulty,
+		seedText:      m.seedText(),
+		board:         m.board,
+		initialBoard:  m.initialBoard,
+		notes:         m.notes,
+		notesMode:     m.notesMode,
+		cursor:        m.cursor,
+		state:         m.state,
+		cellsLeft:     m.cellsLeft,
+		hintsUsed:     m.hintsUsed,
+		mistakes:      m.mistakes,
+		mistakeLimit:  m.mistakeLimit,
+		elapsedBefore: m.elapsedBefore,
+		startTime:     m.startTime,
+		clockRunning:  m.clockRunning,
+	}
+	for c := range findConflicts(m.board) {
+		game.errs[c.row][c.col] = true
+	}
+	if m.state == Paused {
+		game.board = [sudokuLen][sudokuLen]int{}
+		game.initialBoard = [sudokuLen][sudokuLen]int{}
+		game.notes = [sudokuLen][sudokuLen]candidates{}
+		game.errs = [sudokuLen][sudokuLen]bool{}
+	}
+	liveGames.Publish(game)
+}
+
+// spectatorTickMsg redraws a watched game's clock once a second.
+type spectatorTickMsg struct{}
+
+func spectatorTick() tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return spectatorTickMsg{}
+	})
+}
+
+// SpectatorModel lists the games that can be watched and shows the chosen
+// one live. Spectators can't do anything to the game.
+type SpectatorModel struct {
+	width, height int
+	session       *Session
+	games         []gameSnapshot
+	cursor        int
+	// watching is the game being watched, if any.
+	watching *gameSnapshot
+	notice   string
+	help     help.Model
+}
+
+func NewSpectatorModel(width, height int, session *Session) *SpectatorModel {
+	return &SpectatorModel{
+		width:   width,
+		height:  height,
+		session: session,
+		games:   liveGames.List(session),
+		help:    newHelp(session.Theme(), width),
+	}
+}
+
+func (m SpectatorModel) Init() tea.Cmd {
+	return spectatorTick()
+}
+
+func (m SpectatorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		keys := m.session.KeyMap()
+		switch {
+		case key.Matches(msg, keys.ForceQuit):
+			return m, tea.Quit
+		case key.Matches(msg, keys.Help):
+			m.help.ShowAll = !m.help.ShowAll
+		case m.watching != nil:
+			if key.Matches(msg, keys.Back) {
+				liveGames.Unwatch(m.session)
+				m.watching = nil
+				m.games = liveGames.List(m.session)
+			}
+		case key.Matches(msg, keys.Up):
+			m.cursor = max(0, m.cursor-1)
+		case key.Matches(msg, keys.Down):
+			m.cursor = max(0, min(len(m.games)-1, m.cursor+1))
+		case key.Matches(msg, keys.Select):
+			if m.cursor < len(m.games) {
+				m.notice = ""
+				if game, ok := liveGames.Watch(m.session, m.games[m.cursor].session); ok {
+					m.watching = &game
+				}
+			}
+		case key.Matches(msg, keys.Back):
+			return NewMenuModel(m.width, m.height, m.session), nil
+		}
+
+	case liveGamesChangedMsg:
+		m.games = liveGames.List(m.session)
+		m.cursor = max(0, min(len(m.games)-1, m.cursor))
+
+	case gameSnapshotMsg:
+		if m.watching != nil && msg.game.session == m.watching.session {
+			m.watching = &msg.game
+		}
+
+	case spectatedGameEndedMsg:
+		if m.watching != nil && msg.player == m.watching.session {
+			m.notice = fmt.Sprintf("%s's game has ended", m.watching.name)
+			m.watching = nil
+			m.games = liveGames.List(m.session)
+		}
+
+	case spectatorTickMsg:
+		return m, spectatorTick()
+
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.help.Width = msg.Width
+	}
+	return m, nil
+}
+
+func (m SpectatorModel) View() string {
+	var content string
+	if m.watching != nil {
+		content = m.renderGame()
+	} else {
+		content = m.renderList()
+	}
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, content)
+}
+
+func (m SpectatorModel) helpKeys() help.KeyMap {
+	k := m.session.KeyMap()
+	if m.watching != nil {
+		return bindingHelp{relabel(k.Back, "stop watching"), k.Help}
+	}
+	return bindingHelp{k.Up, k.Down, relabel(k.Select, "watch"), relabel(k.Back, "back to menu"), k.Help}
+}
+
+func (m SpectatorModel) renderList() string {
+	theme := m.session.Theme()
+	var s strings.Builder
+	s.WriteString(lipgloss.NewStyle().Bold(true).Render("Watch a game") + "\n\n")
+	if len(m.games) == 0 {
+		s.WriteString("Nobody who lets others watch is playing right now.\n")
+	}
+	for i, game := range m.games {
+		cursor := "  "
+		if i == m.cursor {
+			cursor = theme.markerStyle().Render("> ")
+		}
+		s.WriteString(fmt.Sprintf("%s%-20s %-6s %2d cells left  %s\n",
+			cursor, truncateString(game.name, 20), game.difficulty, game.cellsLeft, formatDuration(game.elapsed())))
+	}
+	if m.notice != "" {
+		s.WriteString("\n" + theme.failureStyle().Render(m.notice) + "\n")
+	}
+	s.WriteString("\n" + m.help.View(m.helpKeys()))
+	return s.String()
+}
+
+// renderGame mirrors the watched board with the spectator's own theme and
+// highlights.
+func (m SpectatorModel) renderGame() string {
+	game := m.watching
+	mirror := GameModel{
+		board:                   game.board,
+		initialBoard:            game.initialBoard,
+		notes:                   game.notes,
+		notesMode:               game.notesMode,
+		cursor:                  game.cursor,
+		theme:                   m.session.Theme(),
+		highlightPeers:          m.session.Settings.HighlightPeers,
+		highlightMatches:        m.session.Settings.HighlightMatches,
+		remainingErrCoordinates: map[coordinate]bool{},
+		mistakes:                game.mistakes,
+		mistakeLimit:            game.mistakeLimit,
+	}
+	for i := 0; i < sudokuLen; i++ {
+		for j := 0; j < sudokuLen; j++ {
+			if game.errs[i][j] {
+				mirror.remainingErrCoordinates[coordinate{i, j}] = true
+			}
+		}
+	}
+
+	theme := m.session.Theme()
+	elapsed := game.elapsed().Round(time.Second)
+	info := theme.textStyle().Render(fmt.Sprintf("Watching %s - %s - %s\n"+
+		"Elapsed time: %02d:%02d\n"+
+		"Cells left: %d\n"+
+		"Hints used: %d\n"+
+		"Mistakes: %s",
+		game.name, game.difficulty, game.seedText,
+		int(elapsed.Minutes()), int(elapsed.Seconds())%60,
+		game.cellsLeft,
+		game.hintsUsed,
+		mirror.mistakesText()))
+
+	var status string
+	switch game.state {
+	case Won:
+		status = theme.successStyle().Render(game.name + " solved the puzzle!")
+	case Lost:
+		status = theme.failureStyle().Render(game.name + " made too many mistakes")
+	case Paused:
+		status = theme.textStyle().Render(game.name + " paused the game")
+	case InMenu, ViewingLeaderboard:
+		status = theme.textStyle().Render(game.name + " is in the menu")
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Center,
+		mirror.renderBoard(),
+		"",
+		info,
+		status,
+		"",
+		m.help.View(m.helpKeys()))
+}
@@ -0,0 +1,44 @@
+package main
+
+import "testing"
+
+// watchTestGame publishes m to a fresh set of live games and returns what a
+// spectator would see of it.
+func watchTestGame(t *testing.T, m *GameModel) (gameSnapshot, bool) {
+	t.Helper()
+	old := liveGames
+	liveGames = NewLiveGames()
+	t.Cleanup(func() { liveGames = old })
+
+	m.session = &Session{PlayerID: "SHA256:player", Username: "player"}
+	m.spectatable = true
+	m.publish()
+	return liveGames.Watch(&Session{PlayerID: "SHA256:spectator"}, m.session)
+}
+
+func TestPublishOnlyFlagsConflicts(t *testing.T) {
+	m := newTestGame(t)
+	m.assist = AssistStrict
+	m.board[0][2] = 2 // wrong, though nothing it can see shows that
+	m.board[0][3] = 9 // repeats the given 9 in its box
+	m.refreshState()
+
+	game, ok := watchTestGame(t, m)
+	if !ok {
+		t.Fatal("the game wasn't published")
+	}
+	if game.errs[0][2] {
+		t.Error("spectators were told a digit is wrong that only the solution shows")
+	}
+	if !game.errs[0][3] || !game.errs[1][4] {
+		t.Error("spectators weren't shown the repeated 9s")
+	}
+}
+
+func TestDailyGamesAreNotPublished(t *testing.T) {
+	m := newTestGame(t)
+	m.daily = "2026-10-16"
+	if _, ok := watchTestGame(t, m); ok {
+		t.Error("a daily puzzle was shown to spectators")
+	}
+}
@@ -6,77 +6,110 @@ import (
 	"github.com/charmbracelet/lipgloss"
 )
 
-var (
-	cellStyle = func(modifiable bool) lipgloss.Style {
-		if modifiable {
-			return lipgloss.NewStyle().
-				PaddingLeft(1).PaddingRight(1).
-				Background(lipgloss.Color("240")).
-				Foreground(lipgloss.Color("15"))
-		} else {
-			return lipgloss.NewStyle().
-				PaddingLeft(1).PaddingRight(1).
-				Background(lipgloss.Color("236"))
+// cellFlags describes how a single board cell should be drawn.
+type cellFlags struct {
+	isError, isCursor, isHinted, isNotes, modifiable bool
+	// isPeer and isMatch mark cells sharing a unit or a digit with the
+	// cursor's cell.
+	isPeer, isMatch bool
+	// partner is the color of another player's cursor on the cell, if any.
+	partner lipgloss.Color
+}
+
+// cellStyle picks a cell's colors. An error shows over the cursor, which
+// shows over a hint, and so on down to the cell's plain colors.
+func (t *Theme) cellStyle(f cellFlags) lipgloss.Style {
+	pick := func(modifiable, given lipgloss.Color) lipgloss.Color {
+		if f.modifiable {
+			return modifiable
 		}
+		return given
 	}
 
-	cursorCellStyle = func(modifiable bool) lipgloss.Style {
-		if modifiable {
-			return lipgloss.NewStyle().
-				PaddingLeft(1).PaddingRight(1).
-				Background(lipgloss.Color("34"))
-		} else {
-			return lipgloss.NewStyle().
-				PaddingLeft(1).PaddingRight(1).
-				Background(lipgloss.Color("22"))
-		}
+	fg := pick(t.CellText, t.GivenText)
+	var bg lipgloss.Color
+	switch {
+	case f.isError && f.isCursor:
+		bg, fg = t.ErrorCursor, t.ErrorText
+	case f.isError:
+		bg, fg = t.Error, t.ErrorText
+	case f.isCursor:
+		bg = pick(t.Cursor, t.GivenCursor)
+	case f.partner != "":
+		bg = f.partner
+	case f.isHinted:
+		bg = pick(t.Hinted, t.GivenHinted)
+	case f.isMatch:
+		bg = pick(t.Match, t.GivenMatch)
+	case f.isPeer:
+		bg = pick(t.Peer, t.GivenPeer)
+	default:
+		bg = pick(t.Cell, t.Given)
 	}
+	if f.isNotes {
+		fg = t.NotesText
+	}
+	return lipgloss.NewStyle().
+		PaddingLeft(1).PaddingRight(1).
+		Background(bg).
+		Foreground(fg)
+}
 
-	errorCellStyle = func(isCursor bool) lipgloss.Style {
-		if isCursor {
-			return lipgloss.NewStyle().
-				PaddingLeft(1).PaddingRight(1).
-				Background(lipgloss.Color("160")).
-				Foreground(lipgloss.Color("15"))
-		} else {
-			return lipgloss.NewStyle().
-				PaddingLeft(1).PaddingRight(1).
-				Background(lipgloss.Color("196")).
-				Foreground(lipgloss.Color("15"))
-		}
+func (t *Theme) formatCell(f cellFlags, row, col int, c string) string {
+	renderedCell := t.cellStyle(f).Render(c)
+
+	if col+1 == 3 || col+1 == 6 {
+		renderedCell = lipgloss.JoinHorizontal(lipgloss.Top, renderedCell, lipgloss.NewStyle().
+			Border(lipgloss.NormalBorder(), false, true, false, false).
+			Margin(0, 1).
+			Height(lipgloss.Height(renderedCell)).
+			Render(""))
 	}
 
-	formatCell = func(isError, isCursor, modifiable bool, row, col int, c string) string {
-		var s lipgloss.Style
+	return renderedCell
+}
 
-		if isError {
-			s = errorCellStyle(isCursor)
-		} else if isCursor {
-			s = cursorCellStyle(modifiable)
-		} else {
-			s = cellStyle(modifiable)
-		}
+func formatRow(row int, r string) string {
+	if row+1 == 3 || row+1 == 6 {
+		rSize := lipgloss.Width(r)
+		border := strings.Repeat("─", (rSize/3)-1)
+		return r + "\n" + border + "┼" + "─" + border + "┼" + border
+	}
+	return r
+}
 
-		renderedCell := s.Render(c)
+func (t *Theme) textStyle() lipgloss.Style {
+	return lipgloss.NewStyle().Foreground(t.Text)
+}
 
-		if col+1 == 3 || col+1 == 6 {
-			renderedCell += lipgloss.NewStyle().
-				Border(lipgloss.NormalBorder(), false, true, false, false).
-				Margin(0, 1).
-				Render("")
-		}
+func (t *Theme) failureStyle() lipgloss.Style {
+	return lipgloss.NewStyle().Foreground(t.Failure)
+}
 
-		return renderedCell
-	}
+func (t *Theme) successStyle() lipgloss.Style {
+	return lipgloss.NewStyle().Foreground(t.Success)
+}
 
-	formatRow = func(row int, r string) string {
-		if row+1 == 3 || row+1 == 6 {
-			rSize := lipgloss.Width(r)
-			border := strings.Repeat("─", (rSize/3)-1)
-			return r + "\n" + border + "┼" + "─" + border + "┼" + border
-		}
-		return r
-	}
+func (t *Theme) hintTextStyle() lipgloss.Style {
+	return lipgloss.NewStyle().Foreground(t.HintText).Italic(true)
+}
 
-	cellsLeftStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Margin(1, 0, 0, 0)
-)
+func (t *Theme) markerStyle() lipgloss.Style {
+	return lipgloss.NewStyle().Foreground(t.Marker)
+}
+
+// boxStyle is the filled, rounded box of the main menu and win screen.
+func (t *Theme) boxStyle() lipgloss.Style {
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.Box).
+		BorderBackground(t.Box).
+		Background(t.Box)
+}
+
+// boxTextStyle is for text inside boxStyle.
+func (t *Theme) boxTextStyle() lipgloss.Style {
+	return lipgloss.NewStyle().
+		Foreground(t.BoxText).
+		Background(t.Box)
+}
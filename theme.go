@@ -0,0 +1,300 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const themesFileName = "sudoku_themes.toml"
+
+// Theme is a color scheme. Colors are anything lipgloss.Color accepts, i.e.
+// an ANSI color number or a hex code; an empty color leaves the terminal's
+// own.
+type Theme struct {
+	Name string `toml:"name"`
+
+	// Board cells. The Given colors are used for the puzzle's givens and the
+	// others for the cells the player fills in.
+	Cell        lipgloss.Color `toml:"cell"`
+	CellText    lipgloss.Color `toml:"cell_text"`
+	Given       lipgloss.Color `toml:"given"`
+	GivenText   lipgloss.Color `toml:"given_text"`
+	Cursor      lipgloss.Color `toml:"cursor"`
+	GivenCursor lipgloss.Color `toml:"given_cursor"`
+	Hinted      lipgloss.Color `toml:"hinted"`
+	GivenHinted lipgloss.Color `toml:"given_hinted"`
+	Peer        lipgloss.Color `toml:"peer"`
+	GivenPeer   lipgloss.Color `toml:"given_peer"`
+	Match       lipgloss.Color `toml:"match"`
+	GivenMatch  lipgloss.Color `toml:"given_match"`
+	Error       lipgloss.Color `toml:"error"`
+	ErrorCursor lipgloss.Color `toml:"error_cursor"`
+	ErrorText   lipgloss.Color `toml:"error_text"`
+	NotesText   lipgloss.Color `toml:"notes_text"`
+	// DoneText dims the number pad digits that are all placed.
+	DoneText lipgloss.Color `toml:"done_text"`
+	// PartnerCursors mark the other players' cursors in co-op games, each
+	// player taking the next color.
+	PartnerCursors []lipgloss.Color `toml:"partner_cursors"`
+
+	// Text around the board.
+	Text     lipgloss.Color `toml:"text"`
+	Controls lipgloss.Color `toml:"controls"`
+	HintText lipgloss.Color `toml:"hint_text"`
+	Failure  lipgloss.Color `toml:"failure"`
+	Success  lipgloss.Color `toml:"success"`
+	Marker   lipgloss.Color `toml:"marker"`
+
+	// The boxes of the main menu and the win screen.
+	Box         lipgloss.Color `toml:"box"`
+	BoxText     lipgloss.Color `toml:"box_text"`
+	BoxCursor   lipgloss.Color `toml:"box_cursor"`
+	BoxSelected lipgloss.Color `toml:"box_selected"`
+	BoxTitle    lipgloss.Color `toml:"box_title"`
+}
+
+var darkTheme = Theme{
+	Name:           "dark",
+	Cell:           "240",
+	CellText:       "15",
+	Given:          "236",
+	Cursor:         "34",
+	GivenCursor:    "22",
+	Hinted:         "25",
+	GivenHinted:    "17",
+	Peer:           "242",
+	GivenPeer:      "238",
+	Match:          "136",
+	GivenMatch:     "94",
+	Error:          "196",
+	ErrorCursor:    "160",
+	ErrorText:      "15",
+	NotesText:      "245",
+	DoneText:       "240",
+	PartnerCursors: []lipgloss.Color{"166", "129", "31", "162", "64"},
+	Text:           "15",
+	Controls:       "33",
+	HintText:       "117",
+	Failure:        "196",
+	Success:        "#00FF00",
+	Marker:         "205",
+	Box:            "11",
+	BoxText:        "0",
+	BoxCursor:      "9",
+	BoxSelected:    "201",
+	BoxTitle:       "196",
+}
+
+var lightTheme = Theme{
+	Name:           "light",
+	Cell:           "255",
+	CellText:       "16",
+	Given:          "252",
+	GivenText:      "16",
+	Cursor:         "150",
+	GivenCursor:    "114",
+	Hinted:         "153",
+	GivenHinted:    "117",
+	Peer:           "189",
+	GivenPeer:      "183",
+	Match:          "223",
+	GivenMatch:     "180",
+	Error:          "210",
+	ErrorCursor:    "203",
+	ErrorText:      "16",
+	NotesText:      "242",
+	DoneText:       "248",
+	PartnerCursors: []lipgloss.Color{"216", "183", "117", "218", "151"},
+	Text:           "235",
+	Controls:       "25",
+	HintText:       "24",
+	Failure:        "160",
+	Success:        "28",
+	Marker:         "162",
+	Box:            "229",
+	BoxText:        "16",
+	BoxCursor:      "160",
+	BoxSelected:    "127",
+	BoxTitle:       "160",
+}
+
+var highContrastTheme = Theme{
+	Name:           "high-contrast",
+	Cell:           "16",
+	CellText:       "15",
+	Given:          "16",
+	GivenText:      "14",
+	Cursor:         "21",
+	GivenCursor:    "19",
+	Hinted:         "90",
+	GivenHinted:    "54",
+	Peer:           "237",
+	GivenPeer:      "237",
+	Match:          "130",
+	GivenMatch:     "130",
+	Error:          "196",
+	ErrorCursor:    "124",
+	ErrorText:      "15",
+	NotesText:      "250",
+	DoneText:       "240",
+	PartnerCursors: []lipgloss.Color{"202", "129", "33", "199", "40"},
+	Text:           "15",
+	Controls:       "14",
+	HintText:       "14",
+	Failure:        "196",
+	Success:        "46",
+	Marker:         "11",
+	Box:            "15",
+	BoxText:        "16",
+	BoxCursor:      "196",
+	BoxSelected:    "21",
+	BoxTitle:       "196",
+}
+
+var solarizedTheme = Theme{
+	Name:           "solarized",
+	Cell:           "#073642",
+	CellText:       "#93a1a1",
+	Given:          "#002b36",
+	GivenText:      "#839496",
+	Cursor:         "#859900",
+	GivenCursor:    "#586e75",
+	Hinted:         "#268bd2",
+	GivenHinted:    "#2aa198",
+	Peer:           "#174652",
+	GivenPeer:      "#0d3a45",
+	Match:          "#b58900",
+	GivenMatch:     "#cb4b16",
+	Error:          "#dc322f",
+	ErrorCursor:    "#d33682",
+	ErrorText:      "#fdf6e3",
+	NotesText:      "#657b83",
+	DoneText:       "#586e75",
+	PartnerCursors: []lipgloss.Color{"#cb4b16", "#6c71c4", "#2aa198", "#d33682", "#b58900"},
+	Text:           "#93a1a1",
+	Controls:       "#268bd2",
+	HintText:       "#2aa198",
+	Failure:        "#dc322f",
+	Success:        "#859900",
+	Marker:         "#d33682",
+	Box:            "#eee8d5",
+	BoxText:        "#073642",
+	BoxCursor:      "#dc322f",
+	BoxSelected:    "#6c71c4",
+	BoxTitle:       "#cb4b16",
+}
+
+// ThemeRegistry holds the built-in themes followed by those defined in the
+// themes file. It isn't changed after loading, so it needs no lock.
+type ThemeRegistry struct {
+	themes []Theme
+}
+
+var themes = NewThemeRegistry()
+
+func NewThemeRegistry() *ThemeRegistry {
+	return &ThemeRegistry{
+		themes: []Theme{darkTheme, lightTheme, highContrastTheme, solarizedTheme},
+	}
+}
+
+// LoadThemesFromFile adds the themes defined in a TOML file to the built-in
+// ones. Each [[theme]] starts from the theme named by its base key, dark by
+// default, and overrides the colors it sets; a theme named like a built-in
+// one replaces it.
+func LoadThemesFromFile(filename string) (*ThemeRegistry, error) {
+	registry := NewThemeRegistry()
+	var file struct {
+		Themes []toml.Primitive `toml:"theme"`
+	}
+	md, err := toml.DecodeFile(filename, &file)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return registry, nil
+		}
+		return nil, err
+	}
+
+	var errs []error
+	for i, p := range file.Themes {
+		var head struct {
+			Base string `toml:"base"`
+		}
+		if err := md.PrimitiveDecode(p, &head); err != nil {
+			return nil, err
+		}
+		base := registry.find(head.Base)
+		if head.Base == "" {
+			base = &darkTheme
+		} else if base == nil {
+			errs = append(errs, fmt.Errorf("theme %d: unknown base theme %q", i+1, head.Base))
+			continue
+		}
+		theme := *base
+		theme.Name = ""
+		if err := md.PrimitiveDecode(p, &theme); err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(theme.Name) == "" {
+			errs = append(errs, fmt.Errorf("theme %d: name must not be empty", i+1))
+			continue
+		}
+		registry.add(theme)
+	}
+	for _, key := range md.Undecoded() {
+		errs = append(errs, fmt.Errorf("unknown theme setting %q", key.String()))
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return registry, nil
+}
+
+func (r *ThemeRegistry) add(theme Theme) {
+	for i := range r.themes {
+		if r.themes[i].Name == theme.Name {
+			r.themes[i] = theme
+			return
+		}
+	}
+	r.themes = append(r.themes, theme)
+}
+
+func (r *ThemeRegistry) find(name string) *Theme {
+	for i := range r.themes {
+		if r.themes[i].Name == name {
+			return &r.themes[i]
+		}
+	}
+	return nil
+}
+
+// Get returns the named theme, or the first one if there is no such theme,
+// e.g. because it was removed from the themes file.
+func (r *ThemeRegistry) Get(name string) *Theme {
+	if t := r.find(name); t != nil {
+		return t
+	}
+	return &r.themes[0]
+}
+
+// partnerCursor is the cursor color of the co-op player at index i.
+func (t *Theme) partnerCursor(i int) lipgloss.Color {
+	if len(t.PartnerCursors) == 0 {
+		return t.Cursor
+	}
+	return t.PartnerCursors[i%len(t.PartnerCursors)]
+}
+
+func (r *ThemeRegistry) Names() []string {
+	names := make([]string, len(r.themes))
+	for i, t := range r.themes {
+		names[i] = t.Name
+	}
+	return names
+}